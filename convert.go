@@ -41,6 +41,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -52,11 +53,53 @@ var (
 	JSONUnmarshal = json.Unmarshal
 )
 
+// ScanLocation is the location that time.Time values are converted to when
+// scanned into an optional value, for example time.UTC. When nil (the
+// default) times are kept in whatever location the driver returned them in.
+var ScanLocation *time.Location
+
 var errNilPtr = errors.New("destination pointer is nil") // embedded in descriptive error
 
+// timeLayouts are the layouts that strings are parsed with when scanned into
+// a time.Time, in order. The second is the form used by SQLite and MySQL
+// DATETIME columns, it has no zone and so is taken to be UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+}
+
+func parseTime(d *time.Time, src any, s string) error {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			*d = t
+			return nil
+		}
+	}
+	return fmt.Errorf("converting driver.Value type %T (%q) to a time.Time: not RFC3339 or %q", src, s, "2006-01-02 15:04:05")
+}
+
 // ConvertAssign copies to dest the value in src, converting it if possible.
 // An error is returned if the copy would result in loss of information.
 // dest should be a pointer type.
+//
+// Numeric strings such as those returned by drivers for DECIMAL/NUMERIC
+// columns ("123.45", "100.00") are accepted for integer destinations as long
+// as they have no fractional part. For float destinations they are parsed
+// with strconv.ParseFloat and so are subject to the usual float precision
+// limits, a DECIMAL with more significant digits than the float can hold will
+// be rounded.
+//
+// Integer and float kinds convert between each other in either direction,
+// signed or not, as long as the value fits. Overflowing the destination,
+// storing a negative value in an unsigned one, losing a fractional part or
+// an integer too large to be held exactly by a float are all errors rather
+// than silent truncation. Narrowing one float to another only fails if the
+// value overflows, it is otherwise rounded as usual.
+//
+// Strings are parsed into time.Time destinations as RFC3339 or in the
+// "2006-01-02 15:04:05" form (with optional fractional seconds) that some
+// drivers return timestamps in, and time.Time sources are formatted into
+// strings as RFC3339.
 func ConvertAssign(dest, src any) error {
 	// Common cases, without reflect.
 	switch s := src.(type) {
@@ -80,6 +123,11 @@ func ConvertAssign(dest, src any) error {
 			}
 			*d = append((*d)[:0], s...)
 			return nil
+		case *time.Time:
+			if d == nil {
+				return errNilPtr
+			}
+			return parseTime(d, src, s)
 		}
 	case []byte:
 		switch d := dest.(type) {
@@ -107,6 +155,11 @@ func ConvertAssign(dest, src any) error {
 			}
 			*d = s
 			return nil
+		case *time.Time:
+			if d == nil {
+				return errNilPtr
+			}
+			return parseTime(d, src, string(s))
 		}
 	case time.Time:
 		switch d := dest.(type) {
@@ -225,6 +278,13 @@ func ConvertAssign(dest, src any) error {
 		return nil
 	}
 
+	if num, ok, err := numericMethodValue(src, sv, dv.Kind()); ok {
+		if err != nil {
+			return fmt.Errorf("converting driver.Value type %T to a %s: %v", src, dv.Kind(), err)
+		}
+		return ConvertAssign(dest, num)
+	}
+
 	// The following conversions use a string value as an intermediate representation
 	// to convert between various numeric types.
 	//
@@ -243,7 +303,11 @@ func ConvertAssign(dest, src any) error {
 			return fmt.Errorf("converting NULL to %s is unsupported", dv.Kind())
 		}
 		s := asString(src)
-		i64, err := strconv.ParseInt(s, 10, dv.Type().Bits())
+		num, err := decimalInteger(s)
+		if err != nil {
+			return fmt.Errorf("converting driver.Value type %T (%q) to a %s: %v", src, s, dv.Kind(), err)
+		}
+		i64, err := strconv.ParseInt(num, 10, dv.Type().Bits())
 		if err != nil {
 			err = strconvErr(err)
 			return fmt.Errorf("converting driver.Value type %T (%q) to a %s: %v", src, s, dv.Kind(), err)
@@ -255,9 +319,17 @@ func ConvertAssign(dest, src any) error {
 			return fmt.Errorf("converting NULL to %s is unsupported", dv.Kind())
 		}
 		s := asString(src)
-		u64, err := strconv.ParseUint(s, 10, dv.Type().Bits())
+		num, err := decimalInteger(s)
+		if err != nil {
+			return fmt.Errorf("converting driver.Value type %T (%q) to a %s: %v", src, s, dv.Kind(), err)
+		}
+		u64, err := strconv.ParseUint(num, 10, dv.Type().Bits())
 		if err != nil {
 			err = strconvErr(err)
+			if _, ierr := strconv.ParseInt(num, 10, 64); ierr == nil || errors.Is(ierr, strconv.ErrRange) {
+				// A valid negative integer, which is only out of range.
+				err = strconv.ErrRange
+			}
 			return fmt.Errorf("converting driver.Value type %T (%q) to a %s: %v", src, s, dv.Kind(), err)
 		}
 		dv.SetUint(u64)
@@ -272,6 +344,9 @@ func ConvertAssign(dest, src any) error {
 			err = strconvErr(err)
 			return fmt.Errorf("converting driver.Value type %T (%q) to a %s: %v", src, s, dv.Kind(), err)
 		}
+		if isIntegerKind(sv.Kind()) && strconv.FormatFloat(f64, 'f', -1, dv.Type().Bits()) != s {
+			return fmt.Errorf("converting driver.Value type %T (%q) to a %s: %v", src, s, dv.Kind(), errPrecisionLost)
+		}
 		dv.SetFloat(f64)
 		return nil
 	case reflect.String:
@@ -291,6 +366,96 @@ func ConvertAssign(dest, src any) error {
 	return fmt.Errorf("unsupported Scan, storing driver.Value type %T into type %T", src, dest)
 }
 
+var (
+	errFractionLost  = errors.New("fractional part would be lost")
+	errPrecisionLost = errors.New("precision would be lost")
+)
+
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// decimalInteger strips an all-zero fractional part from a decimal string
+// ("100.00" -> "100") so that it can be parsed as an integer. Strings that are
+// not plain decimals are returned as-is for strconv to judge, and a non-zero
+// fraction is an error rather than a silent truncation.
+func decimalInteger(s string) (string, error) {
+	whole, frac, ok := strings.Cut(s, ".")
+	if !ok || len(frac) == 0 {
+		return s, nil
+	}
+
+	digits := strings.TrimLeft(whole, "+-")
+	if len(whole)-len(digits) > 1 || len(digits) == 0 {
+		return s, nil
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return s, nil
+		}
+	}
+
+	for _, r := range frac {
+		switch {
+		case r == '0':
+		case r >= '1' && r <= '9':
+			return "", errFractionLost
+		default:
+			return s, nil
+		}
+	}
+
+	return whole, nil
+}
+
+// int64Valuer and float64Valuer are the method sets probed for on custom
+// numeric types returned by drivers.
+type (
+	int64Valuer   interface{ Int64() (int64, error) }
+	float64Valuer interface{ Float64() (float64, error) }
+)
+
+// numericMethodValue extracts a plain number from a source exposing an
+// Int64() or Float64() method when the destination kind is numeric,
+// preferring Float64 for float destinations and Int64 otherwise. String kinds
+// such as json.Number are left to the string conversions, which also handle
+// forms like "100.00" that their methods would reject.
+func numericMethodValue(src any, sv reflect.Value, kind reflect.Kind) (any, bool, error) {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return nil, false, nil
+	}
+	if !sv.IsValid() || sv.Kind() == reflect.String {
+		return nil, false, nil
+	}
+	// A nil pointer has the methods of its element type but calling them
+	// would dereference it.
+	if sv.Kind() == reflect.Pointer && sv.IsNil() {
+		return nil, false, nil
+	}
+
+	iv, isInt := src.(int64Valuer)
+	fv, isFloat := src.(float64Valuer)
+	isFloatDest := kind == reflect.Float32 || kind == reflect.Float64
+	switch {
+	case isFloat && (isFloatDest || !isInt):
+		f, err := fv.Float64()
+		return f, true, err
+	case isInt:
+		i, err := iv.Int64()
+		return i, true, err
+	}
+	return nil, false, nil
+}
+
 func strconvErr(err error) error {
 	if ne, ok := err.(*strconv.NumError); ok {
 		return ne.Err
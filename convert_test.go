@@ -36,6 +36,7 @@ package opt
 import (
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
 	"runtime"
@@ -115,6 +116,13 @@ var conversionTests = []conversionTest{
 	{s: time.Unix(1, 2).UTC(), d: &scanbytes, wantbytes: []byte("1970-01-01T00:00:01.000000002Z")},
 	{s: time.Unix(1, 2).UTC(), d: &scaniface, wantiface: time.Unix(1, 2).UTC()},
 
+	// To time.Time
+	{s: "1970-01-01T00:00:01.000000002Z", d: &scantime, wanttime: time.Unix(1, 2)},
+	{s: "2016-01-26T22:03:17-08:00", d: &scantime, wanttime: time.Unix(1453874597, 0)},
+	{s: []byte("2016-01-27 06:03:17"), d: &scantime, wanttime: time.Unix(1453874597, 0)},
+	{s: "2016-01-27 06:03:17.5", d: &scantime, wanttime: time.Unix(1453874597, 500000000)},
+	{s: "yesterday", d: &scantime, wanterr: `converting driver.Value type string ("yesterday") to a time.Time: not RFC3339 or "2006-01-02 15:04:05"`},
+
 	// To []byte
 	{s: nil, d: &scanbytes, wantbytes: nil},
 	{s: "string", d: &scanbytes, wantbytes: []byte("string")},
@@ -153,12 +161,47 @@ var conversionTests = []conversionTest{
 	{s: "-1", d: &scanint, wantint: -1},
 	{s: "foo", d: &scanint, wanterr: `converting driver.Value type string ("foo") to a int: invalid syntax`},
 
+	// Decimal strings to integers
+	{s: "100.00", d: &scanint, wantint: 100},
+	{s: []byte("-12.0"), d: &scanint8, wantint: -12},
+	{s: "255.000", d: &scanuint8, wantuint: 255},
+	{s: "256.00", d: &scanuint8, wanterr: `converting driver.Value type string ("256.00") to a uint8: value out of range`},
+	{s: "100.50", d: &scanint, wanterr: `converting driver.Value type string ("100.50") to a int: fractional part would be lost`},
+	{s: "1.0e2", d: &scanint, wanterr: `converting driver.Value type string ("1.0e2") to a int: invalid syntax`},
+
+	// Custom numeric types exposing Int64/Float64 methods
+	{s: fakeInt64{v: 42}, d: &scanint, wantint: 42},
+	{s: fakeInt64{v: 300}, d: &scanuint8, wanterr: `converting driver.Value type int64 ("300") to a uint8: value out of range`},
+	{s: fakeInt64{v: 42}, d: &scanf64, wantf64: 42},
+	{s: fakeInt64{err: errFakeNumeric}, d: &scanint, wanterr: `converting driver.Value type opt.fakeInt64 to a int: not a number`},
+	{s: fakeFloat64{v: 1.5}, d: &scanf32, wantf32: 1.5},
+	{s: fakeFloat64{v: 100}, d: &scanint, wantint: 100},
+	{s: fakeFloat64{v: 1.5}, d: &scanint, wanterr: `converting driver.Value type float64 ("1.5") to a int: fractional part would be lost`},
+	{s: fakeNumeric{i: 7, f: 7.25}, d: &scanint, wantint: 7},
+	{s: fakeNumeric{i: 7, f: 7.25}, d: &scanf64, wantf64: 7.25},
+	{s: (*fakeInt64)(nil), d: &scanint, wanterr: `converting driver.Value type *opt.fakeInt64 ("<nil>") to a int: invalid syntax`},
+
 	// int64 to smaller integers
 	{s: int64(5), d: &scanuint8, wantuint: 5},
 	{s: int64(256), d: &scanuint8, wanterr: `converting driver.Value type int64 ("256") to a uint8: value out of range`},
 	{s: int64(256), d: &scanuint16, wantuint: 256},
 	{s: int64(65536), d: &scanuint16, wanterr: `converting driver.Value type int64 ("65536") to a uint16: value out of range`},
 
+	// Widening and narrowing between numeric kinds
+	{s: uint8(200), d: &scanint, wantint: 200},
+	{s: int8(-5), d: &scanint, wantint: -5},
+	{s: uint64(1 << 63), d: &scanint, wanterr: `converting driver.Value type uint64 ("9223372036854775808") to a int: value out of range`},
+	{s: int64(-1), d: &scanuint8, wanterr: `converting driver.Value type int64 ("-1") to a uint8: value out of range`},
+	{s: "-1", d: &scanuint16, wanterr: `converting driver.Value type string ("-1") to a uint16: value out of range`},
+	{s: float64(-2), d: &scanuint8, wanterr: `converting driver.Value type float64 ("-2") to a uint8: value out of range`},
+	{s: float64(2), d: &scanint8, wantint: 2},
+	{s: int64(16777216), d: &scanf32, wantf32: 16777216},
+	{s: int64(16777217), d: &scanf32, wanterr: `converting driver.Value type int64 ("16777217") to a float32: precision would be lost`},
+	{s: uint64(1<<53 + 1), d: &scanf64, wanterr: `converting driver.Value type uint64 ("9007199254740993") to a float64: precision would be lost`},
+	{s: int32(-7), d: &scanf64, wantf64: -7},
+	{s: float64(1e300), d: &scanf32, wanterr: `converting driver.Value type float64 ("1e+300") to a float32: value out of range`},
+	{s: float32(1.5), d: &scanf64, wantf64: 1.5},
+
 	// True bools
 	{s: true, d: &scanbool, wantbool: true},
 	{s: "True", d: &scanbool, wantbool: true},
@@ -187,6 +230,8 @@ var conversionTests = []conversionTest{
 	{s: float64(1.5), d: &scanf32, wantf32: float32(1.5)},
 	{s: "1.5", d: &scanf32, wantf32: float32(1.5)},
 	{s: "1.5", d: &scanf64, wantf64: float64(1.5)},
+	{s: "123.45", d: &scanf64, wantf64: float64(123.45)},
+	{s: []byte("-0.125"), d: &scanf32, wantf32: float32(-0.125)},
 
 	// Pointers
 	{s: any(nil), d: &scanptr, wantnil: true},
@@ -219,6 +264,29 @@ func intValue(intptr any) int64 {
 	return reflect.Indirect(reflect.ValueOf(intptr)).Int()
 }
 
+var errFakeNumeric = errors.New("not a number")
+
+// fakeInt64, fakeFloat64 and fakeNumeric mimic the custom numeric types some
+// drivers return.
+type fakeInt64 struct {
+	v   int64
+	err error
+}
+
+func (f fakeInt64) Int64() (int64, error) { return f.v, f.err }
+
+type fakeFloat64 struct{ v float64 }
+
+func (f fakeFloat64) Float64() (float64, error) { return f.v, nil }
+
+type fakeNumeric struct {
+	i int64
+	f float64
+}
+
+func (f fakeNumeric) Int64() (int64, error)     { return f.i, nil }
+func (f fakeNumeric) Float64() (float64, error) { return f.f, nil }
+
 func uintValue(intptr any) uint64 {
 	return reflect.Indirect(reflect.ValueOf(intptr)).Uint()
 }
@@ -0,0 +1,90 @@
+package opt
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	enums    sync.Map // map[reflect.Type]enumNames
+	hasEnums atomic.Bool
+)
+
+// enumNames is the name mapping of a single enum type.
+type enumNames struct {
+	names  map[any]string
+	values map[string]any
+}
+
+// RegisterEnum registers names for the values of the enum type E. Optional
+// values holding an E then marshal to and from JSON as those names rather
+// than as the underlying value. Unset and null values are unaffected.
+//
+// Marshaling a value that has no registered name and unmarshaling an unknown
+// name are both errors. Registering E again replaces its names.
+func RegisterEnum[E comparable](names map[E]string) {
+	e := enumNames{
+		names:  make(map[any]string, len(names)),
+		values: make(map[string]any, len(names)),
+	}
+	for val, name := range names {
+		e.names[val] = name
+		e.values[name] = val
+	}
+
+	enums.Store(reflect.TypeFor[E](), e)
+	hasEnums.Store(true)
+}
+
+func lookupEnum[T any]() (enumNames, bool) {
+	if !hasEnums.Load() {
+		return enumNames{}, false
+	}
+	e, ok := enums.Load(reflect.TypeFor[T]())
+	if !ok {
+		return enumNames{}, false
+	}
+	return e.(enumNames), true
+}
+
+// MarshalValueJSON marshals the payload of an optional value. It uses the
+// names registered with RegisterEnum if there are any for T and JSONMarshal
+// otherwise.
+//
+// The payload is marshaled through a pointer so that a json.Marshaler
+// implemented on *T is used even though the payload is stored by value.
+func MarshalValueJSON[T any](val T) ([]byte, error) {
+	e, ok := lookupEnum[T]()
+	if !ok {
+		return JSONMarshal(&val)
+	}
+
+	name, ok := e.names[val]
+	if !ok {
+		return nil, fmt.Errorf("no name registered for enum %T value %v", val, val)
+	}
+	return JSONMarshal(name)
+}
+
+// UnmarshalValueJSON unmarshals the payload of an optional value. It uses the
+// names registered with RegisterEnum if there are any for T and JSONUnmarshal
+// otherwise.
+func UnmarshalValueJSON[T any](data []byte, val *T) error {
+	e, ok := lookupEnum[T]()
+	if !ok {
+		return JSONUnmarshal(data, val)
+	}
+
+	var name string
+	if err := JSONUnmarshal(data, &name); err != nil {
+		return err
+	}
+	enumVal, ok := e.values[name]
+	if !ok {
+		return fmt.Errorf("unknown name %q for enum %T", name, *val)
+	}
+	*val = enumVal.(T)
+	return nil
+}
@@ -0,0 +1,86 @@
+package opt
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testColor int
+
+const (
+	testColorRed testColor = iota
+	testColorBlue
+	testColorUnnamed
+)
+
+func init() {
+	RegisterEnum(map[testColor]string{
+		testColorRed:  "red",
+		testColorBlue: "blue",
+	})
+}
+
+func TestEnumJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := MarshalValueJSON(testColorBlue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"blue"` {
+		t.Error("wrong json:", string(b))
+	}
+
+	if _, err := MarshalValueJSON(testColorUnnamed); err == nil {
+		t.Error("expected an error for a value without a name")
+	}
+
+	var color testColor
+	if err := UnmarshalValueJSON([]byte(`"red"`), &color); err != nil {
+		t.Fatal(err)
+	}
+	if color != testColorRed {
+		t.Error("wrong value:", color)
+	}
+
+	if err := UnmarshalValueJSON([]byte(`"green"`), &color); err == nil {
+		t.Error("expected an error for an unknown name")
+	}
+	if err := UnmarshalValueJSON([]byte(`1`), &color); err == nil {
+		t.Error("expected an error for a non-name")
+	}
+
+	var i int
+	if err := UnmarshalValueJSON([]byte(`1`), &i); err != nil || i != 1 {
+		t.Error("unregistered types should be untouched:", i, err)
+	}
+}
+
+// ptrMarshaler implements json.Marshaler on its pointer only.
+type ptrMarshaler struct {
+	N int
+}
+
+func (p *ptrMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"n=%d"`, p.N)), nil
+}
+
+func TestMarshalValueJSONPointerReceiver(t *testing.T) {
+	t.Parallel()
+
+	b, err := MarshalValueJSON(ptrMarshaler{N: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"n=5"` {
+		t.Error("wrong json:", string(b))
+	}
+
+	b, err = MarshalValueJSON([]ptrMarshaler{{N: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `["n=1"]` {
+		t.Error("wrong json:", string(b))
+	}
+}
@@ -2,4 +2,9 @@ module github.com/blink-io/opt
 
 go 1.24
 
-require github.com/aarondl/opt v0.0.0-20250607033636-982744e1bd65
+require (
+	github.com/aarondl/opt v0.0.0-20250607033636-982744e1bd65
+	github.com/caarlos0/env/v11 v11.4.1
+	github.com/google/go-cmp v0.7.0
+	github.com/guregu/null/v5 v5.0.0
+)
@@ -0,0 +1,37 @@
+package globaldata
+
+import (
+	"reflect"
+	"sync"
+)
+
+type implementsKey struct {
+	typ, intf reflect.Type
+}
+
+// implementsCache remembers the result of Implements per type and interface.
+var implementsCache sync.Map // map[implementsKey]bool
+
+// Implements reports whether typ implements the interface type intf, which
+// must have exported methods. The answer is computed once per pair so that
+// hot marshaling paths don't walk method sets on every call. A nil typ, such
+// as the type of a nil interface value, implements nothing.
+func Implements(typ, intf reflect.Type) bool {
+	if typ == nil {
+		return false
+	}
+	// Most payloads are plain types without methods, for which this is
+	// far cheaper than a cache lookup.
+	if typ.Kind() != reflect.Interface && typ.NumMethod() == 0 {
+		return false
+	}
+
+	key := implementsKey{typ: typ, intf: intf}
+	if ok, found := implementsCache.Load(key); found {
+		return ok.(bool)
+	}
+
+	ok := typ.Implements(intf)
+	implementsCache.Store(key, ok)
+	return ok
+}
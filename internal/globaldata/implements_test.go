@@ -0,0 +1,41 @@
+package globaldata
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestImplements(t *testing.T) {
+	t.Parallel()
+
+	types := []struct {
+		typ  reflect.Type
+		text bool
+	}{
+		{reflect.TypeFor[net.IP](), true},
+		{reflect.TypeFor[*time.Time](), true},
+		{reflect.TypeFor[string](), false},
+		{nil, false},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, tc := range types {
+				if got := Implements(tc.typ, EncodingTextMarshalerIntf); got != tc.text {
+					t.Errorf("%v: want %t, got %t", tc.typ, tc.text, got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if Implements(reflect.TypeFor[net.IP](), DriverValuerIntf) {
+		t.Error("the answer for one interface must not leak into another")
+	}
+}
@@ -0,0 +1,49 @@
+package globaldata
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+)
+
+// CallValuerValue returns vr.Value(), with one exception:
+// If vr.Value is an auto-generated method on a pointer type and the
+// pointer is nil, it would panic at runtime in the panicwrap
+// method. Treat it like nil instead.
+// Issue 8415.
+//
+// This is so people can implement driver.Value on value types and
+// still use nil pointers to those types to mean nil/NULL, just like
+// string/*string.
+//
+// This function is mirrored in the database/sql package.
+func CallValuerValue(vr driver.Valuer) (v driver.Value, err error) {
+	if rv := reflect.ValueOf(vr); rv.Kind() == reflect.Pointer &&
+		rv.IsNil() &&
+		Implements(rv.Type().Elem(), DriverValuerIntf) {
+		return nil, nil
+	}
+	return vr.Value()
+}
+
+// ScanSource prepares a value passed to the Scan method of a Val[T] for
+// conversion. A source that is itself a driver.Valuer, as some drivers and
+// wrappers hand back, is replaced by its Value unless it is already a T. A
+// sql.RawBytes is copied since its memory is reused by the next scan.
+func ScanSource[T any](src any) (any, error) {
+	if vr, ok := src.(driver.Valuer); ok {
+		if _, isT := src.(T); !isT {
+			sv, err := CallValuerValue(vr)
+			if err != nil {
+				return nil, err
+			}
+			src = sv
+		}
+	}
+
+	if raw, ok := src.(sql.RawBytes); ok {
+		return bytes.Clone(raw), nil
+	}
+	return src, nil
+}
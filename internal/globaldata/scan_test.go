@@ -0,0 +1,40 @@
+package globaldata
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+type driverInt int64
+
+func (i driverInt) Value() (driver.Value, error) {
+	return int64(i), nil
+}
+
+func TestScanSource(t *testing.T) {
+	t.Parallel()
+
+	src, err := ScanSource[int](driverInt(5))
+	if err != nil || src != int64(5) {
+		t.Error("valuer should be resolved:", src, err)
+	}
+	src, err = ScanSource[driverInt](driverInt(5))
+	if err != nil || src != driverInt(5) {
+		t.Error("a valuer that is already a T should be kept:", src, err)
+	}
+	src, err = ScanSource[int]((*driverInt)(nil))
+	if err != nil || src != nil {
+		t.Error("nil pointer valuer should be nil:", src, err)
+	}
+
+	raw := sql.RawBytes("hello")
+	src, err = ScanSource[[]byte](raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := src.([]byte)
+	if !ok || string(b) != "hello" || &b[0] == &raw[0] {
+		t.Error("raw bytes should be copied:", src)
+	}
+}
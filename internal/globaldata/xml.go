@@ -0,0 +1,32 @@
+package globaldata
+
+import "encoding/xml"
+
+// XMLSchemaInstance is the namespace of the xsi:nil attribute.
+const XMLSchemaInstance = "http://www.w3.org/2001/XMLSchema-instance"
+
+// XMLNilElement returns start marked with xsi:nil="true". The xsi prefix is
+// declared on the element itself so the output stands alone.
+func XMLNilElement(start xml.StartElement) xml.StartElement {
+	start.Attr = append(start.Attr[:len(start.Attr):len(start.Attr)],
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: XMLSchemaInstance},
+		xml.Attr{Name: xml.Name{Local: "xsi:nil"}, Value: "true"},
+	)
+	return start
+}
+
+// IsXMLNil reports whether start carries xsi:nil="true". The prefix is
+// accepted whether or not it was declared, in which case the decoder leaves
+// it untranslated.
+func IsXMLNil(start xml.StartElement) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Local != "nil" {
+			continue
+		}
+		if attr.Name.Space != XMLSchemaInstance && attr.Name.Space != "xsi" {
+			continue
+		}
+		return attr.Value == "true" || attr.Value == "1"
+	}
+	return false
+}
@@ -6,8 +6,12 @@ import (
 	"bytes"
 	"database/sql/driver"
 	"encoding"
+	"encoding/gob"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/blink-io/opt"
 	"github.com/blink-io/opt/internal/globaldata"
@@ -99,6 +103,28 @@ func (v Val[T]) GetOrZero() T {
 	return v.value
 }
 
+// GetOrErr returns the value, or the zero value and err if it is null. It
+// is for code where a missing value is an error:
+//
+//	id, err := req.ID.GetOrErr(errMissingID)
+func (v Val[T]) GetOrErr(err error) (T, error) {
+	if v.state != StateSet {
+		var t T
+		return t, err
+	}
+	return v.value, nil
+}
+
+// GetOrErrf is GetOrErr with an error made by fmt.Errorf, which is only
+// called if the value is null.
+func (v Val[T]) GetOrErrf(format string, args ...any) (T, error) {
+	if v.state != StateSet {
+		var t T
+		return t, fmt.Errorf(format, args...)
+	}
+	return v.value, nil
+}
+
 // MustGet retrieves the value or panics if it's null
 func (v Val[T]) MustGet() T {
 	val, ok := v.Get()
@@ -127,6 +153,28 @@ func (v Val[T]) Or(other Val[T]) Val[T] {
 	}
 }
 
+// OrElse is a lazy Or, fn is only called to build the fallback when v is not
+// set.
+func (v Val[T]) OrElse(fn func() Val[T]) Val[T] {
+	if v.state == StateSet {
+		return v
+	}
+	return v.Or(fn())
+}
+
+// Coalesce returns the first set value in vals, like the SQL function of the
+// same name, or a null value if none are set.
+func Coalesce[T any](vals ...Val[T]) Val[T] {
+	var result Val[T]
+	for _, v := range vals {
+		if v.state == StateSet {
+			return v
+		}
+		result = result.Or(v)
+	}
+	return result
+}
+
 // Map transforms the value inside if it is set, else it returns a value of the
 // same state.
 //
@@ -149,6 +197,17 @@ func Map[A any, B any](v Val[A], fn func(A) B) Val[B] {
 	return Val[B]{state: v.state}
 }
 
+// FlatMap is Map for a transform that itself returns an optional value, it
+// avoids ending up with a Val[Val[B]]. It short-circuits: fn is only called
+// when v is set, otherwise a null value is returned without calling fn. When
+// fn is called its result is returned as-is.
+func FlatMap[A any, B any](v Val[A], fn func(A) Val[B]) Val[B] {
+	if v.state == StateSet {
+		return fn(v.value)
+	}
+	return Val[B]{state: v.state}
+}
+
 // Set the value (and the state to 'set')
 func (v *Val[T]) Set(val T) {
 	v.value = val
@@ -195,7 +254,8 @@ func (v Val[T]) IfNull(then func()) {
 	}
 }
 
-// Ptr returns a pointer to the value, or nil if null.
+// Ptr returns a pointer to a copy of the value, or nil if null. Changes made
+// through the pointer do not affect v.
 func (v Val[T]) Ptr() *T {
 	if v.state == StateSet {
 		return &v.value
@@ -209,7 +269,13 @@ func (v Val[T]) State() state {
 }
 
 // UnmarshalJSON implements json.Unmarshaler
+//
+// Empty (or all whitespace) data is an error as it is neither null nor a
+// value, the json package never passes it. Whitespace around a null is
+// ignored.
 func (v *Val[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
 	switch {
 	case len(data) == 0:
 		return errors.New("cannot unmarshal empty bytes into null value")
@@ -219,7 +285,7 @@ func (v *Val[T]) UnmarshalJSON(data []byte) error {
 		v.state = StateNull
 		return nil
 	default:
-		err := opt.JSONUnmarshal(data, &v.value)
+		err := opt.UnmarshalValueJSON(data, &v.value)
 		if err != nil {
 			return err
 		}
@@ -232,12 +298,47 @@ func (v *Val[T]) UnmarshalJSON(data []byte) error {
 func (v Val[T]) MarshalJSON() ([]byte, error) {
 	switch v.state {
 	case StateSet:
-		return opt.JSONMarshal(v.value)
+		return opt.MarshalValueJSON(v.value)
 	default:
 		return globaldata.JSONNull, nil
 	}
 }
 
+// MarshalXML implements xml.Marshaler. A null value emits an empty element
+// marked with xsi:nil="true", a set value is encoded as T would be.
+func (v Val[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if v.state != StateSet {
+		start = globaldata.XMLNilElement(start)
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		return e.EncodeToken(start.End())
+	}
+	return e.EncodeElement(v.value, start)
+}
+
+// UnmarshalXML implements xml.Unmarshaler. An element marked with
+// xsi:nil="true" results in a null value, anything else is decoded as T.
+func (v *Val[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if globaldata.IsXMLNil(start) {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+		var zero T
+		v.value = zero
+		v.state = StateNull
+		return nil
+	}
+
+	var val T
+	if err := d.DecodeElement(&val, &start); err != nil {
+		return err
+	}
+	v.value = val
+	v.state = StateSet
+	return nil
+}
+
 // MarshalText implements encoding.TextMarshaler.
 //
 // This package emits an empty string for null values
@@ -254,22 +355,14 @@ func (v Val[T]) MarshalJSON() ([]byte, error) {
 // any value being consumed by null.UnmarshalText() has been
 // created with null.MarshalText() and it therefore strives to make
 // no gesture of compatibility with non-null.Val serialized types.
+//
+// Set values are encoded by opt.MarshalValueText, which falls back to JSON
+// for structs, maps and slices.
 func (v Val[T]) MarshalText() ([]byte, error) {
 	if v.state != StateSet {
 		return nil, nil
 	}
-
-	refVal := reflect.ValueOf(v.value)
-	if refVal.Type().Implements(globaldata.EncodingTextMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextMarshaler)
-		return valuer.MarshalText()
-	}
-
-	var text string
-	if err := opt.ConvertAssign(&text, v.value); err != nil {
-		return nil, err
-	}
-	return []byte(text), nil
+	return opt.MarshalValueText(v.value)
 }
 
 // UnmarshalText implements encoding.TextUnmarshaler.
@@ -285,43 +378,47 @@ func (v *Val[T]) UnmarshalText(text []byte) error {
 		return nil
 	}
 
-	refVal := reflect.ValueOf(&v.value)
-	if refVal.Type().Implements(globaldata.EncodingTextUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextUnmarshaler)
-		if err := valuer.UnmarshalText(text); err != nil {
-			return err
-		}
-		v.state = StateSet
-		return nil
-	}
-
-	if err := opt.ConvertAssign(&v.value, string(text)); err != nil {
+	// Decode into a temporary so that v is untouched if decoding fails.
+	var val T
+	if err := opt.UnmarshalValueText(text, &val); err != nil {
 		return err
 	}
 
+	v.value = val
 	v.state = StateSet
 	return nil
 }
 
-// MarshalBinary tries to encode the value in binary. If it finds
-// type that implements encoding.BinaryMarshaler it will use that,
-// it will fallback to encoding.TextMarshaler if that is implemented,
-// and failing that it will attempt to do some reflect to convert between
-// the types to hit common cases like Go primitives.
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding is a single
+// state byte (0 for null, 1 for set) followed, when set, by the binary
+// encoding of the value. If its type implements encoding.BinaryMarshaler it
+// will use that, it will fallback to encoding.TextMarshaler if that is
+// implemented, and failing that it will attempt to do some reflect to convert
+// between the types to hit common cases like Go primitives.
 func (v Val[T]) MarshalBinary() ([]byte, error) {
 	if v.state != StateSet {
-		return nil, nil
+		return []byte{0}, nil
 	}
 
-	refVal := reflect.ValueOf(v.value)
-	if refVal.Type().Implements(globaldata.EncodingBinaryMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.BinaryMarshaler)
-		return valuer.MarshalBinary()
+	out := []byte{1}
+
+	valType := reflect.TypeOf(v.value)
+	if globaldata.Implements(valType, globaldata.EncodingBinaryMarshalerIntf) {
+		valuer := any(v.value).(encoding.BinaryMarshaler)
+		b, err := valuer.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return append(out, b...), nil
 	}
 
-	if refVal.Type().Implements(globaldata.EncodingTextMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextMarshaler)
-		return valuer.MarshalText()
+	if globaldata.Implements(valType, globaldata.EncodingTextMarshalerIntf) {
+		valuer := any(v.value).(encoding.TextMarshaler)
+		b, err := valuer.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return append(out, b...), nil
 	}
 
 	var buf []byte
@@ -329,57 +426,141 @@ func (v Val[T]) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
-	return buf, nil
+	return append(out, buf...), nil
 }
 
-// UnmarshalBinary tries to reverse the value MarshalBinary operation.
-// See documentation there for details about supported types.
+// UnmarshalBinary reverses MarshalBinary, see there for the format and
+// supported types. Input that is empty, has an unknown state byte or carries
+// data after the null state byte is an error, and v is left untouched when
+// an error is returned.
 func (v *Val[T]) UnmarshalBinary(b []byte) error {
-	if b == nil {
+	if len(b) == 0 {
+		return errors.New("invalid binary format for null.Val: truncated input, missing state byte")
+	}
+
+	switch b[0] {
+	case 0:
+		if len(b) != 1 {
+			return errors.New("invalid binary format for null.Val: unexpected data after null state")
+		}
 		var zero T
 		v.value = zero
 		v.state = StateNull
 		return nil
+	case 1:
+	default:
+		return fmt.Errorf("invalid binary format for null.Val: unknown state byte %d", b[0])
 	}
 
-	refVal := reflect.ValueOf(&v.value)
-	if refVal.Type().Implements(globaldata.EncodingBinaryUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.BinaryUnmarshaler)
-		if err := valuer.UnmarshalBinary(b); err != nil {
+	// Decode into a temporary so that v is untouched if decoding fails.
+	var val T
+	payload := b[1:]
+	ptrType := reflect.TypeFor[*T]()
+	if globaldata.Implements(ptrType, globaldata.EncodingBinaryUnmarshalerIntf) {
+		valuer := any(&val).(encoding.BinaryUnmarshaler)
+		if err := valuer.UnmarshalBinary(payload); err != nil {
 			return err
 		}
-		v.state = StateSet
-		return nil
+	} else if globaldata.Implements(ptrType, globaldata.EncodingTextUnmarshalerIntf) {
+		valuer := any(&val).(encoding.TextUnmarshaler)
+		if err := valuer.UnmarshalText(payload); err != nil {
+			return err
+		}
+	} else if err := opt.ConvertAssign(&val, payload); err != nil {
+		return err
 	}
 
-	if refVal.Type().Implements(globaldata.EncodingTextUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextUnmarshaler)
-		if err := valuer.UnmarshalText(b); err != nil {
-			return err
+	v.value = val
+	v.state = StateSet
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder, without it gob would silently drop the
+// unexported fields of a Val. It uses the same state byte as MarshalBinary (0
+// for null, 1 for set) followed, when set, by the gob encoding of the value.
+func (v Val[T]) GobEncode() ([]byte, error) {
+	if v.state != StateSet {
+		return []byte{0}, nil
+	}
+
+	buf := bytes.NewBuffer([]byte{1})
+	if err := gob.NewEncoder(buf).Encode(&v.value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder, reversing GobEncode. Truncated input
+// is an error and v is left untouched when an error is returned.
+func (v *Val[T]) GobDecode(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("invalid gob format for null.Val: truncated input, missing state byte")
+	}
+
+	switch b[0] {
+	case 0:
+		if len(b) != 1 {
+			return errors.New("invalid gob format for null.Val: unexpected data after null state")
 		}
-		v.state = StateSet
+		var zero T
+		v.value = zero
+		v.state = StateNull
 		return nil
+	case 1:
+	default:
+		return fmt.Errorf("invalid gob format for null.Val: unknown state byte %d", b[0])
 	}
 
-	if err := opt.ConvertAssign(&v.value, b); err != nil {
+	var val T
+	if err := gob.NewDecoder(bytes.NewReader(b[1:])).Decode(&val); err != nil {
 		return err
 	}
-
+	v.value = val
 	v.state = StateSet
 	return nil
 }
 
 // Scan implements the sql.Scanner interface. If the wrapped type implements
-// sql.Scanner then it will call that.
+// sql.Scanner then it will call that. Scanned time.Time values are converted
+// to opt.ScanLocation if it is set. A driver.Valuer source is first replaced
+// by its Value unless it is already a T, and sql.RawBytes is copied rather
+// than retained.
+//
+// Scanning NULL is handled differently by each of the optional packages,
+// pick the one that matches the column:
+//
+//	package     NULL         value
+//	omit        error        set
+//	null        null         set
+//	omitnull    null         set
+//
+// omitnull never scans as unset, as a column is always present in a row.
 func (v *Val[T]) Scan(value any) error {
+	value, err := globaldata.ScanSource[T](value)
+	if err != nil {
+		return err
+	}
+
 	if value == nil {
 		var zero T
 		v.value = zero
 		v.state = StateNull
 		return nil
 	}
+
+	// Scan into a temporary so that v is untouched if the conversion fails.
+	var val T
+	if err := opt.ConvertAssign(&val, value); err != nil {
+		return err
+	}
+
+	if t, ok := any(&val).(*time.Time); ok && opt.ScanLocation != nil {
+		*t = t.In(opt.ScanLocation)
+	}
+
+	v.value = val
 	v.state = StateSet
-	return opt.ConvertAssign(&v.value, value)
+	return nil
 }
 
 // Value implements the driver.Valuer interface. If the underlying type
@@ -397,6 +578,13 @@ func (v Val[T]) Value() (driver.Value, error) {
 		return nil, nil
 	}
 
+	// Payloads that are already driver values need no conversion, skip the
+	// interface checks and reflection of ToDriverValue for them.
+	switch val := any(v.value).(type) {
+	case int64, float64, bool, string, []byte, time.Time:
+		return val, nil
+	}
+
 	return opt.ToDriverValue(v.value)
 }
 
@@ -413,3 +601,17 @@ func Equal[T comparable](a, b Val[T]) bool {
 
 	return a.value == b.value
 }
+
+// EqualFunc is Equal for any T, using eq to compare set values. It is useful
+// for values that are not comparable such as slices.
+func EqualFunc[T any](a, b Val[T], eq func(T, T) bool) bool {
+	if a.state != b.state {
+		return false
+	}
+
+	if a.state != StateSet {
+		return true
+	}
+
+	return eq(a.value, b.value)
+}
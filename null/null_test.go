@@ -3,7 +3,12 @@ package null
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/gob"
+	"encoding/xml"
+	"errors"
 	"net"
+	"reflect"
+	"slices"
 	"testing"
 	"time"
 
@@ -106,6 +111,60 @@ func TestOr(t *testing.T) {
 	}
 }
 
+func TestGetOrErr(t *testing.T) {
+	t.Parallel()
+
+	errMissing := errors.New("missing")
+	if v, err := From(5).GetOrErr(errMissing); err != nil || v != 5 {
+		t.Error("wrong result:", v, err)
+	}
+	if v, err := (Val[int]{}).GetOrErr(errMissing); !errors.Is(err, errMissing) || v != 0 {
+		t.Error("expected the error, got:", v, err)
+	}
+
+	if v, err := From(5).GetOrErrf("missing %s", "id"); err != nil || v != 5 {
+		t.Error("wrong result:", v, err)
+	}
+	if _, err := (Val[int]{}).GetOrErrf("missing %s: %w", "id", errMissing); err == nil ||
+		err.Error() != "missing id: missing" || !errors.Is(err, errMissing) {
+		t.Error("wrong error:", err)
+	}
+}
+
+func TestOrElse(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	fallback := func() Val[int] {
+		called = true
+		return From(6)
+	}
+
+	if From(5).OrElse(fallback).MustGet() != 5 {
+		t.Error("it should have returned 5")
+	}
+	if called {
+		t.Error("fallback should not be called when set")
+	}
+	if (Val[int]{}).OrElse(fallback).MustGet() != 6 {
+		t.Error("it should have returned 6")
+	}
+	if !called {
+		t.Error("fallback should be called when null")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	t.Parallel()
+
+	var null Val[int]
+	if val := Coalesce(null, From(5), From(6)); val.MustGet() != 5 {
+		t.Error("it should have returned 5")
+	}
+	checkState(t, Coalesce(null, null), StateNull)
+	checkState(t, Coalesce[int](), StateNull)
+}
+
 func TestMap(t *testing.T) {
 	t.Parallel()
 
@@ -125,6 +184,50 @@ func TestMap(t *testing.T) {
 	}
 }
 
+func TestFlatMap(t *testing.T) {
+	t.Parallel()
+
+	names := map[int]string{1: "one"}
+	lookup := func(i int) Val[string] {
+		name, ok := names[i]
+		if !ok {
+			return Val[string]{}
+		}
+		return From(name)
+	}
+
+	if FlatMap(From(1), lookup).MustGet() != "one" {
+		t.Error("wrong value")
+	}
+	checkState(t, FlatMap(From(2), lookup), StateNull)
+	called := false
+	checkState(t, FlatMap(Val[int]{}, func(int) Val[string] {
+		called = true
+		return From("")
+	}), StateNull)
+	if called {
+		t.Error("fn should not be called when v is not set")
+	}
+}
+
+func TestPtr(t *testing.T) {
+	t.Parallel()
+
+	val := From(5)
+	ptr := val.Ptr()
+	if ptr == nil || *ptr != 5 {
+		t.Fatal("wrong pointer")
+	}
+	*ptr = 6
+	if val.MustGet() != 5 {
+		t.Error("mutating through the pointer should not change the value")
+	}
+
+	if (Val[int]{}).Ptr() != nil {
+		t.Error("null should be nil")
+	}
+}
+
 func TestChanges(t *testing.T) {
 	t.Parallel()
 
@@ -159,6 +262,29 @@ func TestMarshalJSON(t *testing.T) {
 	checkJSON(t, val, `null`)
 }
 
+func TestUnmarshalJSONPadding(t *testing.T) {
+	t.Parallel()
+
+	val := From("hello")
+	if err := val.UnmarshalJSON(nil); err == nil {
+		t.Error("empty input should be an error")
+	}
+	if err := val.UnmarshalJSON([]byte(" \n\t")); err == nil {
+		t.Error("whitespace only input should be an error")
+	}
+	checkState(t, val, StateSet)
+
+	if err := val.UnmarshalJSON([]byte(" null ")); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateNull)
+
+	if err := val.UnmarshalJSON([]byte(` "hello" `)); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateSet)
+}
+
 func TestUnmarshalJSON(t *testing.T) {
 	t.Parallel()
 
@@ -184,6 +310,59 @@ func TestUnmarshalJSON(t *testing.T) {
 	}
 }
 
+type xmlAddress struct {
+	City Val[string] `xml:"city"`
+	Zip  Val[int]    `xml:"zip"`
+}
+
+type xmlPerson struct {
+	XMLName xml.Name        `xml:"person"`
+	Name    Val[string]     `xml:"name"`
+	Age     Val[int]        `xml:"age"`
+	Address Val[xmlAddress] `xml:"address"`
+}
+
+func TestXML(t *testing.T) {
+	t.Parallel()
+
+	in := xmlPerson{
+		Name:    From("hello"),
+		Address: From(xmlAddress{City: From("Paris")}),
+	}
+	b, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nilAttrs := `xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"`
+	want := `<person><name>hello</name><age ` + nilAttrs + `></age>` +
+		`<address><city>Paris</city><zip ` + nilAttrs + `></zip></address></person>`
+	if string(b) != want {
+		t.Errorf("wrong xml:\nwant: %s\ngot:  %s", want, b)
+	}
+
+	out := xmlPerson{Age: From(5)}
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name.MustGet() != "hello" {
+		t.Error("wrong name:", out.Name)
+	}
+	checkState(t, out.Age, StateNull)
+	address := out.Address.MustGet()
+	if address.City.MustGet() != "Paris" {
+		t.Error("wrong city:", address.City)
+	}
+	checkState(t, address.Zip, StateNull)
+
+	// The xsi prefix is commonly declared once on an enclosing element.
+	doc := `<person xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><name xsi:nil="true"/></person>`
+	out = xmlPerson{Name: From("hello")}
+	if err := xml.Unmarshal([]byte(doc), &out); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, out.Name, StateNull)
+}
+
 func TestMarshalText(t *testing.T) {
 	t.Parallel()
 
@@ -281,34 +460,113 @@ func TestUnmarshalBinary(t *testing.T) {
 
 	var val Val[string]
 	checkState(t, val, StateNull)
-	if err := val.UnmarshalBinary([]byte("hello")); err != nil {
+	if err := val.UnmarshalBinary([]byte("\x01hello")); err != nil {
 		t.Error(err)
 	}
 	checkState(t, val, StateSet)
 	if val.MustGet() != "hello" {
 		t.Error("wrong value")
 	}
-	if err := val.UnmarshalBinary(nil); err != nil {
+	if err := val.UnmarshalBinary([]byte{0}); err != nil {
 		t.Error(err)
 	}
 	checkState(t, val, StateNull)
 
 	var unmarshaller Val[net.IP]
-	if err := unmarshaller.UnmarshalBinary([]byte{}); err != nil {
+	if err := unmarshaller.UnmarshalBinary([]byte{1}); err != nil {
 		t.Error(err)
 	}
 	checkState(t, unmarshaller, StateSet)
 	if !unmarshaller.MustGet().Equal(nil) {
-		t.Error("expected the IP to be nil as that's what happens when given an empty byte slice")
+		t.Error("expected the IP to be nil as that's what happens when given an empty payload")
 	}
 
-	if err := unmarshaller.UnmarshalBinary([]byte("1.1.1.1")); err != nil {
+	if err := unmarshaller.UnmarshalBinary([]byte("\x011.1.1.1")); err != nil {
 		t.Error(err)
 	}
 	checkState(t, unmarshaller, StateSet)
 	if !unmarshaller.MustGet().Equal(net.IPv4(1, 1, 1, 1)) {
 		t.Error("wrong value")
 	}
+
+	val = From("hello")
+	for _, bad := range [][]byte{nil, {}, {2}, {0, 1}} {
+		if err := val.UnmarshalBinary(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+		if val.MustGet() != "hello" {
+			t.Errorf("%q: the value should be untouched", bad)
+		}
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []Val[string]{From("hello"), From(""), {}} {
+		b, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out Val[string]
+		if err := out.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(in, out) {
+			t.Errorf("round trip changed %v into %v", in, out)
+		}
+	}
+
+	b, err := From(net.IPv4(1, 1, 1, 1)).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte("\x011.1.1.1")) {
+		t.Errorf("wrong encoding: %q", b)
+	}
+	if b, _ := (Val[string]{}).MarshalBinary(); !bytes.Equal(b, []byte{0}) {
+		t.Errorf("wrong null encoding: %q", b)
+	}
+}
+
+type gobRecord struct {
+	Name    Val[string]
+	Count   Val[int]
+	Zero    Val[int]
+	Tags    Val[[]string]
+	Created Val[time.Time]
+	Missing Val[float64]
+}
+
+func TestGob(t *testing.T) {
+	t.Parallel()
+
+	in := gobRecord{
+		Name:    From("hello"),
+		Count:   From(5),
+		Zero:    From(0),
+		Tags:    From([]string{"a", "b"}),
+		Created: From(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out gobRecord
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip mismatch:\nwant: %#v\ngot:  %#v", in, out)
+	}
+	var val Val[int]
+	if err := val.GobDecode([]byte{2}); err == nil {
+		t.Error("expected an error for an unknown state byte")
+	}
+	if err := val.GobDecode(nil); err == nil {
+		t.Error("expected an error for truncated input")
+	}
 }
 
 func TestScan(t *testing.T) {
@@ -329,6 +587,23 @@ func TestScan(t *testing.T) {
 	}
 }
 
+func TestScanNull(t *testing.T) {
+	t.Parallel()
+
+	val := From(5)
+	if err := val.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateNull)
+
+	// A nil Valuer is resolved to NULL before the state is decided.
+	val = From(5)
+	if err := val.Scan((*valuerImplementation)(nil)); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateNull)
+}
+
 type valuerImplementation struct{}
 
 func (valuerImplementation) Value() (driver.Value, error) {
@@ -387,6 +662,31 @@ func TestStateStringer(t *testing.T) {
 	_ = state(99).String()
 }
 
+func TestEqualFunc(t *testing.T) {
+	t.Parallel()
+
+	a := Val[[]int]{}
+	b := Val[[]int]{}
+	if !EqualFunc(a, b, slices.Equal) {
+		t.Error("should be equal")
+	}
+
+	a.Set([]int{1, 2})
+	if EqualFunc(a, b, slices.Equal) {
+		t.Error("should not be equal")
+	}
+
+	b.Set([]int{1, 2})
+	if !EqualFunc(a, b, slices.Equal) {
+		t.Error("should be equal")
+	}
+
+	b.Set([]int{2, 1})
+	if EqualFunc(a, b, slices.Equal) {
+		t.Error("should not be equal")
+	}
+}
+
 func checkState[T any](t *testing.T, val Val[T], state state) {
 	t.Helper()
 
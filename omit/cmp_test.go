@@ -0,0 +1,37 @@
+package omit
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEqualMethodCmp(t *testing.T) {
+	t.Parallel()
+
+	type profile struct {
+		Name Val[string]
+		Tags Val[[]string]
+		Age  Val[int]
+	}
+
+	a := profile{Name: From("hello"), Tags: From([]string{"a", "b"})}
+	b := profile{Name: From("hello"), Tags: From([]string{"a", "b"})}
+	if diff := cmp.Diff(a, b); diff != "" {
+		t.Error("matching structs should have no diff:", diff)
+	}
+
+	b.Age = From(0)
+	if cmp.Equal(a, b) {
+		t.Error("unset and set zero should differ")
+	}
+	b.Age.Unset()
+	b.Tags = From([]string{"a"})
+	if diff := cmp.Diff(a, b); diff == "" {
+		t.Error("different values should have a diff")
+	}
+
+	if !cmp.Equal(From(5).WithState(StateUnset), Val[int]{}) {
+		t.Error("unset values should be equal whatever their payload")
+	}
+}
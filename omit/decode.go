@@ -0,0 +1,142 @@
+package omit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DecodeOptions alter how UnmarshalJSONWith decodes a value. The zero value
+// gives the standard behavior.
+type DecodeOptions struct {
+	// EmptyStringAsUnset makes an empty JSON string ("") decode as unset
+	// rather than as a set empty string. Useful for forms where an empty
+	// field means the value was left out. It only applies to values whose
+	// T is of string kind, for any other T "" is decoded (and most likely
+	// rejected) as usual.
+	EmptyStringAsUnset bool
+
+	// NullAsUnset makes a JSON null decode as unset rather than being an
+	// error, for clients that send null to mean "don't touch this field".
+	NullAsUnset bool
+
+	// RejectDuplicateKeys makes a JSON object holding the same key more than
+	// once, at any depth of the value, an error. The json package otherwise
+	// quietly keeps the last one, which can be used to smuggle a value past
+	// a check that saw the first. Keys that differ only in case count as
+	// duplicates, as the json package matches struct fields that way.
+	RejectDuplicateKeys bool
+
+	// MaxBytes is the largest input, in bytes, that will be decoded. Larger
+	// input is an error before any of it is parsed, as a guard against
+	// oversized untrusted payloads. Zero, the default, means no limit. Note
+	// that the json package has already read the whole document by the time
+	// a Val sees its part of it, use an io.LimitReader or
+	// http.MaxBytesReader to bound that.
+	MaxBytes int
+}
+
+// JSONDecodeOptions supplies the options that a Decoding decodes with. It is
+// implemented by an empty type declared for the purpose:
+//
+//	type formOptions struct{}
+//
+//	func (formOptions) DecodeOptions() omit.DecodeOptions {
+//		return omit.DecodeOptions{EmptyStringAsUnset: true}
+//	}
+type JSONDecodeOptions interface {
+	DecodeOptions() DecodeOptions
+}
+
+// Decoding is a Val that unmarshals with UnmarshalJSONWith and the options
+// supplied by O, so that the json package decodes a struct field with them.
+// All the other methods of Val are available on it.
+//
+//	type Form struct {
+//		Name omit.Decoding[string, formOptions] `json:"name"`
+//	}
+type Decoding[T any, O JSONDecodeOptions] struct {
+	Val[T]
+}
+
+// UnmarshalJSON implements json.Unmarshaler with UnmarshalJSONWith.
+func (d *Decoding[T, O]) UnmarshalJSON(data []byte) error {
+	var opts O
+	return d.Val.UnmarshalJSONWith(data, opts.DecodeOptions())
+}
+
+var emptyJSONString = []byte(`""`)
+
+// checkDuplicateKeys returns an error if any object in the JSON data has a
+// key more than once.
+func checkDuplicateKeys(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return checkDuplicateKeysValue(dec)
+}
+
+func checkDuplicateKeysValue(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		seen := map[string]string{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			folded := foldKey(key)
+			if prev, ok := seen[folded]; ok {
+				if prev == key {
+					return fmt.Errorf("duplicate key %q in json object", key)
+				}
+				return fmt.Errorf("duplicate key %q in json object, matching %q", key, prev)
+			}
+			seen[folded] = key
+
+			if err := checkDuplicateKeysValue(dec); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for dec.More() {
+			if err := checkDuplicateKeysValue(dec); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// The closing delimiter.
+	_, err = dec.Token()
+	return err
+}
+
+// foldKey folds the case of an object key the way the json package does when
+// matching it to a struct field, so that keys it would treat as the same
+// field fold to the same string.
+func foldKey(key string) string {
+	out := make([]byte, 0, len(key))
+	for i := 0; i < len(key); {
+		if c := key[i]; c < utf8.RuneSelf {
+			if 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			out = append(out, c)
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(key[i:])
+		out = utf8.AppendRune(out, unicode.ToUpper(unicode.ToLower(r)))
+		i += n
+	}
+	return string(out)
+}
@@ -0,0 +1,179 @@
+package omit
+
+import (
+	"testing"
+
+	"github.com/blink-io/opt"
+)
+
+func TestEmptyStringAsUnset(t *testing.T) {
+	t.Parallel()
+
+	var val Val[string]
+	if err := val.UnmarshalJSONWith([]byte(`""`), DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateSet)
+	if val.MustGet() != "" {
+		t.Error("wrong value")
+	}
+
+	opts := DecodeOptions{EmptyStringAsUnset: true}
+	if err := val.UnmarshalJSONWith([]byte(`""`), opts); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateUnset)
+
+	if err := val.UnmarshalJSONWith([]byte(`"hi"`), opts); err != nil {
+		t.Fatal(err)
+	}
+	if val.MustGet() != "hi" {
+		t.Error("wrong value")
+	}
+
+	type name string
+	var named Val[name]
+	if err := named.UnmarshalJSONWith([]byte(`""`), opts); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, named, StateUnset)
+
+	num := From(5)
+	if err := num.UnmarshalJSONWith([]byte(`""`), opts); err == nil {
+		t.Error("an empty string should still be a type error for an int")
+	}
+	if num.MustGet() != 5 {
+		t.Error("a failed decode should leave the value alone")
+	}
+}
+
+func TestNullAsUnset(t *testing.T) {
+	t.Parallel()
+
+	val := From(5)
+	if err := val.UnmarshalJSONWith([]byte(`null`), DecodeOptions{}); err == nil {
+		t.Error("null should be an error by default")
+	}
+	if val.MustGet() != 5 {
+		t.Error("a failed decode should leave the value alone")
+	}
+
+	if err := val.UnmarshalJSONWith([]byte(`null`), DecodeOptions{NullAsUnset: true}); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateUnset)
+
+	if err := val.UnmarshalJSON([]byte(`null`)); err == nil {
+		t.Error("UnmarshalJSON should still reject null")
+	}
+}
+
+func TestRejectDuplicateKeys(t *testing.T) {
+	t.Parallel()
+
+	type account struct {
+		Role string `json:"role"`
+	}
+
+	data := []byte(`{"role":"user","role":"admin"}`)
+
+	var val Val[account]
+	if err := val.UnmarshalJSONWith(data, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if val.MustGet().Role != "admin" {
+		t.Error("the default should keep the last key:", val.MustGet())
+	}
+
+	val = Val[account]{}
+	if err := val.UnmarshalJSONWith(data, DecodeOptions{RejectDuplicateKeys: true}); err == nil {
+		t.Error("expected an error for a duplicate key")
+	}
+	checkState(t, val, StateUnset)
+
+	// The json package matches struct fields without regard to case, so
+	// keys differing only in case smuggle a value just the same.
+	for _, data := range []string{
+		`{"role":"user","Role":"admin"}`,
+		`{"role":"user","ROLE":"admin"}`,
+		"{\"Kelvin\":1,\"\u212aelvin\":2}",
+	} {
+		val = Val[account]{}
+		if err := val.UnmarshalJSONWith([]byte(data), DecodeOptions{RejectDuplicateKeys: true}); err == nil {
+			t.Error("expected an error for keys differing in case:", data)
+		}
+		checkState(t, val, StateUnset)
+	}
+
+	nested := []byte(`{"a":[{"b":1},{"b":2,"c":{"d":1,"d":2}}]}`)
+	var anyVal Val[map[string]any]
+	if err := anyVal.UnmarshalJSONWith(nested, DecodeOptions{RejectDuplicateKeys: true}); err == nil {
+		t.Error("expected an error for a nested duplicate key")
+	}
+
+	ok := []byte(`{"a":[{"b":1},{"b":2}],"c":{"b":3}}`)
+	if err := anyVal.UnmarshalJSONWith(ok, DecodeOptions{RejectDuplicateKeys: true}); err != nil {
+		t.Error("the same key in different objects is fine:", err)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	opts := DecodeOptions{MaxBytes: 7}
+
+	var val Val[string]
+	if err := val.UnmarshalJSONWith([]byte(`"hello"`), opts); err != nil {
+		t.Fatal(err)
+	}
+	if val.MustGet() != "hello" {
+		t.Error("wrong value:", val.MustGet())
+	}
+
+	if err := val.UnmarshalJSONWith([]byte(`"goodbye"`), opts); err == nil {
+		t.Error("expected an error for input over the limit")
+	}
+	if val.MustGet() != "hello" {
+		t.Error("oversized input should leave the value alone")
+	}
+
+	if err := val.UnmarshalJSONWith([]byte(`"goodbye, this is long"`), DecodeOptions{}); err != nil {
+		t.Error("no limit by default:", err)
+	}
+}
+
+type formOptions struct{}
+
+func (formOptions) DecodeOptions() DecodeOptions {
+	return DecodeOptions{EmptyStringAsUnset: true, NullAsUnset: true}
+}
+
+func TestDecoding(t *testing.T) {
+	t.Parallel()
+
+	type form struct {
+		Plain Val[string]                   `json:"plain"`
+		Name  Decoding[string, formOptions] `json:"name"`
+		Email Decoding[string, formOptions] `json:"email"`
+	}
+
+	var f form
+	if err := opt.JSONUnmarshal([]byte(`{"plain":"","name":"","email":null}`), &f); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, f.Plain, StateSet)
+	checkState(t, f.Name.Val, StateUnset)
+	checkState(t, f.Email.Val, StateUnset)
+
+	if err := opt.JSONUnmarshal([]byte(`{"plain":null}`), &f); err == nil {
+		t.Error("a plain Val should still reject null")
+	}
+
+	b, err := opt.JSONMarshal(form{Name: Decoding[string, formOptions]{From("bob")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"plain":null,"name":"bob","email":null}` {
+		t.Error("wrong output:", string(b))
+	}
+}
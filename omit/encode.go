@@ -0,0 +1,308 @@
+package omit
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/blink-io/opt"
+	"github.com/blink-io/opt/internal/globaldata"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	jsonRawType       = reflect.TypeFor[json.RawMessage]()
+)
+
+// Marshal encodes v as JSON like json.Marshal, except that unset Val fields
+// are left out of their struct entirely rather than being written as null.
+// This holds at any depth, including inside set Val payloads, slices and
+// maps. It is an alternative to tagging every field with `omitzero`.
+//
+// Struct fields are named and their tag options (omitempty, omitzero and
+// string) applied as the json package does, and types implementing
+// json.Marshaler encode themselves. Fields of untagged embedded structs are
+// promoted, but without the json package's rules for resolving conflicting
+// names.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalIndent is like Marshal but indents the output as json.MarshalIndent
+// does, for human readable dumps that still leave out unset fields.
+func MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	b, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, prefix, indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeJSON(buf *bytes.Buffer, rv reflect.Value) error {
+	if !rv.IsValid() {
+		buf.Write(globaldata.JSONNull)
+		return nil
+	}
+
+	if rv.CanInterface() {
+		if val, ok := rv.Interface().(omittable); ok {
+			return encodeValJSON(buf, rv, val)
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			buf.Write(globaldata.JSONNull)
+			return nil
+		}
+		if rv.Kind() == reflect.Interface || !implementsMarshaler(rv) {
+			return encodeJSON(buf, rv.Elem())
+		}
+	case reflect.Struct:
+		if !implementsMarshaler(rv) {
+			return encodeStructJSON(buf, rv)
+		}
+	case reflect.Slice:
+		if rv.IsNil() {
+			buf.Write(globaldata.JSONNull)
+			return nil
+		}
+		if rv.Type().Elem().Kind() != reflect.Uint8 && !implementsMarshaler(rv) {
+			return encodeListJSON(buf, rv)
+		}
+	case reflect.Array:
+		if !implementsMarshaler(rv) {
+			return encodeListJSON(buf, rv)
+		}
+	case reflect.Map:
+		if rv.IsNil() {
+			buf.Write(globaldata.JSONNull)
+			return nil
+		}
+		if !implementsMarshaler(rv) {
+			return encodeMapJSON(buf, rv)
+		}
+	}
+
+	return encodeLeafJSON(buf, rv)
+}
+
+// encodeValJSON encodes a Val whose payload may itself contain Vals.
+// Payloads that cannot contain fields are left to the Val's own MarshalJSON
+// as it knows about things like registered enums.
+func encodeValJSON(buf *bytes.Buffer, rv reflect.Value, val omittable) error {
+	if val.IsUnset() {
+		buf.Write(globaldata.JSONNull)
+		return nil
+	}
+
+	payload := reflect.ValueOf(val.boxed())
+	if payload.IsValid() {
+		switch payload.Kind() {
+		case reflect.Struct, reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			return encodeJSON(buf, payload)
+		}
+	}
+	return encodeLeafJSON(buf, rv)
+}
+
+func encodeLeafJSON(buf *bytes.Buffer, rv reflect.Value) error {
+	var v any
+	if rv.CanAddr() && !globaldata.Implements(rv.Type(), jsonMarshalerType) &&
+		globaldata.Implements(reflect.PointerTo(rv.Type()), jsonMarshalerType) {
+		v = rv.Addr().Interface()
+	} else {
+		v = rv.Interface()
+	}
+
+	b, err := opt.JSONMarshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// implementsMarshaler reports whether rv encodes itself, in which case its
+// contents are not walked.
+func implementsMarshaler(rv reflect.Value) bool {
+	t := rv.Type()
+	return globaldata.Implements(t, jsonMarshalerType) ||
+		(rv.CanAddr() && globaldata.Implements(reflect.PointerTo(t), jsonMarshalerType))
+}
+
+func encodeListJSON(buf *bytes.Buffer, rv reflect.Value) error {
+	buf.WriteByte('[')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeJSON(buf, rv.Index(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// encodeMapJSON encodes the values of the map itself and leaves the keys,
+// which have their own encoding and ordering rules, to the json package.
+func encodeMapJSON(buf *bytes.Buffer, rv reflect.Value) error {
+	raw := reflect.MakeMapWithSize(reflect.MapOf(rv.Type().Key(), jsonRawType), rv.Len())
+
+	iter := rv.MapRange()
+	for iter.Next() {
+		var elem bytes.Buffer
+		if err := encodeJSON(&elem, iter.Value()); err != nil {
+			return err
+		}
+		raw.SetMapIndex(iter.Key(), reflect.ValueOf(json.RawMessage(elem.Bytes())))
+	}
+
+	return encodeLeafJSON(buf, raw)
+}
+
+func encodeStructJSON(buf *bytes.Buffer, rv reflect.Value) error {
+	buf.WriteByte('{')
+	if _, err := appendStructFieldsJSON(buf, rv, true); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func appendStructFieldsJSON(buf *bytes.Buffer, rv reflect.Value, first bool) (bool, error) {
+	tags := []string{"json"}
+
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := rv.Field(i)
+
+		if _, ok := embeddedStruct(field, tags); ok {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			var err error
+			if first, err = appendStructFieldsJSON(buf, fv, first); err != nil {
+				return first, err
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		name := tagName(field, tags...)
+		if name == "" {
+			continue
+		}
+
+		_, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if val, ok := fv.Interface().(omittable); ok && val.IsUnset() {
+			continue
+		}
+		if hasTagOption(opts, "omitempty") && isEmptyJSONValue(fv) {
+			continue
+		}
+		if hasTagOption(opts, "omitzero") && isZeroJSONValue(fv) {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		key, err := opt.JSONMarshal(name)
+		if err != nil {
+			return first, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		if !hasTagOption(opts, "string") || !quotableKind(fv.Kind()) {
+			if err := encodeJSON(buf, fv); err != nil {
+				return first, err
+			}
+			continue
+		}
+
+		var elem bytes.Buffer
+		if err := encodeJSON(&elem, fv); err != nil {
+			return first, err
+		}
+		quoted, err := opt.JSONMarshal(elem.String())
+		if err != nil {
+			return first, err
+		}
+		buf.Write(quoted)
+	}
+
+	return first, nil
+}
+
+func hasTagOption(opts, want string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == want {
+			return true
+		}
+	}
+	return false
+}
+
+// quotableKind reports whether the string tag option applies to the kind.
+func quotableKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	}
+	return false
+}
+
+// isEmptyJSONValue mirrors the json package's test for omitempty.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// isZeroJSONValue mirrors the json package's test for omitzero, preferring
+// an IsZero method when there is one.
+func isZeroJSONValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return true
+		}
+		return z.IsZero()
+	}
+	return v.IsZero()
+}
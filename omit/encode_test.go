@@ -0,0 +1,115 @@
+package omit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type encodeAddress struct {
+	City Val[string] `json:"city"`
+	Zip  Val[string] `json:"zip"`
+}
+
+type encodeMeta struct {
+	Source Val[string] `json:"source"`
+}
+
+type encodeConfig struct {
+	encodeMeta
+
+	Name     Val[string]            `json:"name"`
+	Port     Val[int]               `json:"port"`
+	Address  Val[encodeAddress]     `json:"address"`
+	Backup   *encodeAddress         `json:"backup,omitempty"`
+	Servers  []encodeAddress        `json:"servers"`
+	Labels   map[string]Val[string] `json:"labels"`
+	Started  Val[time.Time]         `json:"started"`
+	Retries  int                    `json:"retries,string"`
+	Comment  string                 `json:"comment,omitempty"`
+	Disabled Val[bool]              `json:"-"`
+}
+
+func TestMarshalIndent(t *testing.T) {
+	t.Parallel()
+
+	cfg := encodeConfig{
+		encodeMeta: encodeMeta{Source: From("file")},
+		Name:       From("api"),
+		Address:    From(encodeAddress{City: From("Paris")}),
+		Servers:    []encodeAddress{{Zip: From("75001")}},
+		Labels:     map[string]Val[string]{"b": From("x"), "a": {}},
+		Retries:    3,
+		Disabled:   From(true),
+	}
+
+	b, err := MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "source": "file",
+  "name": "api",
+  "address": {
+    "city": "Paris"
+  },
+  "servers": [
+    {
+      "zip": "75001"
+    }
+  ],
+  "labels": {
+    "a": null,
+    "b": "x"
+  },
+  "retries": "3"
+}`
+	if string(b) != want {
+		t.Errorf("wrong output:\nwant: %s\ngot:  %s", want, b)
+	}
+
+	// Apart from the omissions it should agree with the json package.
+	cfg.Port.Set(8080)
+	cfg.Started.Set(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	cfg.Address = From(encodeAddress{City: From("Paris"), Zip: From("75001")})
+	cfg.Servers[0].City.Set("Paris")
+	cfg.Labels["a"] = From("y")
+	cfg.Backup = &encodeAddress{City: From("Lyon"), Zip: From("69001")}
+	cfg.Comment = "hi"
+
+	got, err := Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(std) {
+		t.Errorf("output differs from the json package:\nwant: %s\ngot:  %s", std, got)
+	}
+}
+
+func TestMarshalTopLevel(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in   any
+		want string
+	}{
+		{nil, `null`},
+		{From(5), `5`},
+		{Val[int]{}, `null`},
+		{[]Val[int]{From(1), {}}, `[1,null]`},
+		{&encodeAddress{City: From("Paris")}, `{"city":"Paris"}`},
+		{[]byte("hi"), `"aGk="`},
+	} {
+		b, err := Marshal(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tc.want {
+			t.Errorf("%#v: want %s, got %s", tc.in, tc.want, b)
+		}
+	}
+}
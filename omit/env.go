@@ -0,0 +1,61 @@
+package omit
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// LoadEnv fills the unset Val fields of the struct pointed to by dst from
+// environment variables named prefix followed by the field's Go name in
+// UPPER_SNAKE case, so with a prefix of "APP_" the field DatabaseURL is read
+// from APP_DATABASE_URL. Values are decoded with UnmarshalText. Fields that
+// are already set and variables that are not present are left alone.
+func LoadEnv(dst any, prefix string) error {
+	if rv := reflect.ValueOf(dst); rv.Kind() != reflect.Pointer {
+		return fmt.Errorf("cannot load env into non-pointer type %T", dst)
+	}
+
+	fields, err := valFields(dst, WithTags())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if !f.val.IsUnset() {
+			continue
+		}
+
+		name := prefix + upperSnake(f.field.Name)
+		text, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := f.ptr().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+			return fmt.Errorf("load env %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// upperSnake converts a Go name to UPPER_SNAKE case, keeping initialisms
+// together so HTTPPort becomes HTTP_PORT.
+func upperSnake(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package omit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+)
+
+func TestLoadEnv(t *testing.T) {
+	type config struct {
+		DatabaseURL Val[string]
+		HTTPPort    Val[int]
+		Debug       Val[bool]
+		Name        Val[string]
+		Missing     Val[string]
+	}
+
+	t.Setenv("APP_DATABASE_URL", "postgres://localhost")
+	t.Setenv("APP_HTTP_PORT", "8080")
+	t.Setenv("APP_DEBUG", "true")
+	t.Setenv("APP_NAME", "from env")
+
+	cfg := config{Name: From("kept")}
+	if err := LoadEnv(&cfg, "APP_"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DatabaseURL.GetOrZero() != "postgres://localhost" {
+		t.Error("wrong url:", cfg.DatabaseURL)
+	}
+	if cfg.HTTPPort.GetOrZero() != 8080 {
+		t.Error("wrong port:", cfg.HTTPPort)
+	}
+	if !cfg.Debug.GetOrZero() {
+		t.Error("debug should be set:", cfg.Debug)
+	}
+	if cfg.Name.GetOrZero() != "kept" {
+		t.Error("set fields should not be overridden:", cfg.Name)
+	}
+	checkState(t, cfg.Missing, StateUnset)
+
+	t.Setenv("APP_HTTP_PORT", "not a number")
+	cfg = config{}
+	if err := LoadEnv(&cfg, "APP_"); err == nil {
+		t.Error("expected an error for a bad port")
+	}
+}
+
+func TestUpperSnake(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"Name":        "NAME",
+		"DatabaseURL": "DATABASE_URL",
+		"HTTPPort":    "HTTP_PORT",
+		"MaxConns2":   "MAX_CONNS2",
+		"V2Api":       "V2_API",
+	}
+	for in, want := range tests {
+		if got := upperSnake(in); got != want {
+			t.Errorf("%s: want %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestCaarlos0Env(t *testing.T) {
+	type config struct {
+		Host    Val[string]        `env:"HOST"`
+		Port    Val[int]           `env:"PORT"`
+		Timeout Val[time.Duration] `env:"TIMEOUT"`
+		Missing Val[string]        `env:"MISSING"`
+	}
+
+	t.Setenv("ENVTEST_HOST", "localhost")
+	t.Setenv("ENVTEST_PORT", "8080")
+
+	var cfg config
+	if err := env.ParseWithOptions(&cfg, env.Options{Prefix: "ENVTEST_"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host.GetOrZero() != "localhost" {
+		t.Error("wrong host:", cfg.Host)
+	}
+	if cfg.Port.GetOrZero() != 8080 {
+		t.Error("wrong port:", cfg.Port)
+	}
+	checkState(t, cfg.Timeout, StateUnset)
+	checkState(t, cfg.Missing, StateUnset)
+
+	t.Setenv("ENVTEST_PORT", "not a number")
+	if err := env.ParseWithOptions(&cfg, env.Options{Prefix: "ENVTEST_"}); err == nil {
+		t.Error("expected an error for a bad port")
+	}
+}
@@ -0,0 +1,99 @@
+package omit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/blink-io/opt"
+)
+
+var rawMessageMapType = reflect.TypeFor[map[string]json.RawMessage]()
+
+// UnmarshalJSONExtras behaves like UnmarshalJSON but when T is a struct (or a
+// pointer to one) that has an extras field, any keys in data that do not
+// correspond to a field of T are stored in it rather than being dropped.
+//
+// The extras field must be of type map[string]json.RawMessage and is found
+// either by an `opt:"extras"` tag or by being named Extras. It should be
+// tagged `json:"-"` so the json package itself leaves it alone.
+//
+//	type Payload struct {
+//		Name   string                     `json:"name"`
+//		Extras map[string]json.RawMessage `json:"-"`
+//	}
+//
+// Keys are matched against field names case-insensitively, the same as the
+// json package does.
+func (v *Val[T]) UnmarshalJSONExtras(data []byte) error {
+	if err := v.UnmarshalJSON(data); err != nil || v.state != StateSet {
+		return err
+	}
+
+	rv := reflect.ValueOf(&v.value).Elem()
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	extras, ok := extrasField(rv)
+	if !ok {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := opt.JSONUnmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	known := jsonNames(rv.Type())
+	for key, msg := range raw {
+		if isKnownName(known, key) {
+			continue
+		}
+		if extras.IsNil() {
+			extras.Set(reflect.MakeMap(rawMessageMapType))
+		}
+		extras.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(msg))
+	}
+
+	return nil
+}
+
+// extrasField finds the field designated to hold unknown keys.
+func extrasField(rv reflect.Value) (reflect.Value, bool) {
+	t := rv.Type()
+
+	byName := -1
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type != rawMessageMapType {
+			continue
+		}
+		if field.Tag.Get("opt") == "extras" {
+			return rv.Field(i), true
+		}
+		if field.Name == "Extras" {
+			byName = i
+		}
+	}
+
+	if byName < 0 {
+		return reflect.Value{}, false
+	}
+	return rv.Field(byName), true
+}
+
+func isKnownName(known []string, key string) bool {
+	for _, name := range known {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package omit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type extrasPayload struct {
+	Name   string                     `json:"name"`
+	Age    int                        `json:"age,omitempty"`
+	Extras map[string]json.RawMessage `json:"-"`
+}
+
+type taggedExtrasPayload struct {
+	Name    string                     `json:"name"`
+	Unknown map[string]json.RawMessage `json:"-" opt:"extras"`
+}
+
+func TestUnmarshalJSONExtras(t *testing.T) {
+	t.Parallel()
+
+	var val Val[extrasPayload]
+	data := []byte(`{"name":"hello","AGE":5,"color":"red","tags":[1,2]}`)
+	if err := val.UnmarshalJSONExtras(data); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateSet)
+
+	payload := val.MustGet()
+	if payload.Name != "hello" || payload.Age != 5 {
+		t.Error("known fields were wrong:", payload)
+	}
+	if len(payload.Extras) != 2 {
+		t.Fatal("expected two extras, got:", payload.Extras)
+	}
+	if string(payload.Extras["color"]) != `"red"` {
+		t.Error("wrong color:", string(payload.Extras["color"]))
+	}
+	if string(payload.Extras["tags"]) != `[1,2]` {
+		t.Error("wrong tags:", string(payload.Extras["tags"]))
+	}
+
+	var tagged Val[*taggedExtrasPayload]
+	if err := tagged.UnmarshalJSONExtras([]byte(`{"name":"hi","other":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(tagged.MustGet().Unknown["other"]); got != "true" {
+		t.Error("wrong value for other:", got)
+	}
+
+	var none Val[extrasPayload]
+	if err := none.UnmarshalJSONExtras([]byte(`{"name":"hi"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if none.MustGet().Extras != nil {
+		t.Error("extras should not be allocated when there are none")
+	}
+
+	if err := none.UnmarshalJSONExtras([]byte(`null`)); err == nil {
+		t.Error("should fail on null")
+	}
+}
@@ -0,0 +1,412 @@
+package omit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+
+	"github.com/blink-io/opt"
+)
+
+// omittable is implemented by every Val regardless of its type parameter,
+// which lets the reflection based helpers find them inside of structs.
+type omittable interface {
+	IsUnset() bool
+	IsZero() bool
+	boxed() any
+}
+
+// omittablePtr is implemented by pointers to every Val.
+type omittablePtr interface {
+	omittable
+	UnmarshalJSON(data []byte) error
+	Unset()
+}
+
+// FieldOption configures how the reflection based helpers read structs.
+type FieldOption func(*fieldOptions)
+
+type fieldOptions struct {
+	tags []string
+}
+
+// WithTags sets the struct tag namespaces (such as "db" or "yaml") used to
+// name fields, in order of preference. The first of them present on a field
+// decides its name, a name of "-" excludes the field and a field with none
+// of them uses its Go name. The default is to use only the json tag.
+func WithTags(tags ...string) FieldOption {
+	return func(o *fieldOptions) {
+		o.tags = tags
+	}
+}
+
+func newFieldOptions(opts []FieldOption) fieldOptions {
+	o := fieldOptions{tags: []string{"json"}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// tagName resolves the name of a struct field from the first of the
+// preferred tag namespaces present on it, falling back to the Go field name.
+// It returns "" if the field is excluded with a "-".
+func tagName(field reflect.StructField, preferred ...string) string {
+	for _, key := range preferred {
+		tag, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		if tag == "-" {
+			return ""
+		}
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name
+		}
+		break
+	}
+	return field.Name
+}
+
+// hasTag reports whether the field has any of the tag namespaces.
+func hasTag(field reflect.StructField, tags []string) bool {
+	for _, key := range tags {
+		if _, ok := field.Tag.Lookup(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddedStruct returns the struct type of an untagged embedded field whose
+// fields should be promoted.
+func embeddedStruct(field reflect.StructField, tags []string) (reflect.Type, bool) {
+	if !field.Anonymous || hasTag(field, tags) {
+		return nil, false
+	}
+	ft := field.Type
+	if ft.Kind() == reflect.Pointer {
+		ft = ft.Elem()
+	}
+	return ft, ft.Kind() == reflect.Struct
+}
+
+// valField is a Val found in a struct by valFields.
+type valField struct {
+	name  string
+	field reflect.StructField
+	value reflect.Value
+	val   omittable
+}
+
+// valFields returns the Val fields of the struct (or pointer to struct) v in
+// declaration order, they are addressable when v is a pointer. Unexported
+// and excluded fields are skipped, and fields of untagged embedded structs
+// are promoted as the json package would.
+func valFields(v any, opts ...FieldOption) ([]valField, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("cannot read fields of nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot read fields of non-struct type %T", v)
+	}
+
+	return appendValFields(nil, rv, newFieldOptions(opts).tags), nil
+}
+
+func appendValFields(fields []valField, rv reflect.Value, tags []string) []valField {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := rv.Field(i)
+
+		if field.IsExported() {
+			if val, ok := fv.Interface().(omittable); ok {
+				if name := tagName(field, tags...); name != "" {
+					fields = append(fields, valField{name: name, field: field, value: fv, val: val})
+				}
+				continue
+			}
+		}
+
+		if _, ok := embeddedStruct(field, tags); ok {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			fields = appendValFields(fields, fv, tags)
+		}
+	}
+	return fields
+}
+
+// ptr returns the pointer methods of the field, it must be addressable.
+func (f valField) ptr() omittablePtr {
+	return f.value.Addr().Interface().(omittablePtr)
+}
+
+// SetFields returns the names of all the set Val fields in the struct (or
+// pointer to struct) v, for quick membership tests such as checking which
+// fields a caller is trying to change. Fields are named by their json tags
+// unless WithTags says otherwise.
+func SetFields(v any, opts ...FieldOption) (map[string]struct{}, error) {
+	fields, err := valFields(v, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		if !f.val.IsUnset() {
+			set[f.name] = struct{}{}
+		}
+	}
+	return set, nil
+}
+
+// UnionFields returns the json names of the Val fields that are set in at
+// least one of the structs (or pointers to structs) vs, such as to find the
+// columns needed for a batch of records. The structs need not be of the same
+// type.
+func UnionFields(vs ...any) (map[string]struct{}, error) {
+	union := map[string]struct{}{}
+	for _, v := range vs {
+		set, err := SetFields(v)
+		if err != nil {
+			return nil, err
+		}
+		for name := range set {
+			union[name] = struct{}{}
+		}
+	}
+	return union, nil
+}
+
+// IterFields iterates over the set Val fields of the struct (or pointer to
+// struct) v in declaration order, yielding each field's name and its boxed
+// value. Unset fields are skipped and anything that is not a struct yields
+// nothing.
+//
+//	for name, val := range omit.IterFields(req) {
+//		...
+//	}
+func IterFields(v any, opts ...FieldOption) iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		fields, err := valFields(v, opts...)
+		if err != nil {
+			return
+		}
+
+		for _, f := range fields {
+			if f.val.IsUnset() {
+				continue
+			}
+			if !yield(f.name, f.val.boxed()) {
+				return
+			}
+		}
+	}
+}
+
+// OrderedFields returns the json names and boxed values of the set Val fields
+// in the struct (or pointer to struct) v in declaration order, for encoders
+// that need a deterministic order. Unset fields are left out.
+func OrderedFields(v any) ([]struct {
+	Key   string
+	Value any
+}, error) {
+	fields, err := valFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]struct {
+		Key   string
+		Value any
+	}, 0, len(fields))
+	for _, f := range fields {
+		if f.val.IsUnset() {
+			continue
+		}
+		ordered = append(ordered, struct {
+			Key   string
+			Value any
+		}{Key: f.name, Value: f.val.boxed()})
+	}
+	return ordered, nil
+}
+
+// WouldOmit returns the json names of the Val fields in the struct (or
+// pointer to struct) v that an omit-aware encoder would drop, such as the
+// std library with an `omitzero` tag or github.com/aarondl/json. This is a
+// debugging aid for checking expectations about encoded output.
+//
+// These are the unset fields as well as set fields holding a nil map, slice
+// or pointer, see IsZero.
+func WouldOmit(v any) ([]string, error) {
+	fields, err := valFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range fields {
+		if f.val.IsZero() {
+			names = append(names, f.name)
+		}
+	}
+	return names, nil
+}
+
+// Fingerprint returns a stable hash of the set Val fields in the struct (or
+// pointer to struct) v, for keying caches by the parts of a filter that were
+// actually given. It covers the json names and values of the set fields only,
+// so structs that agree on those share a fingerprint whatever their unset
+// fields. Values are hashed in their JSON form.
+func Fingerprint(v any) (string, error) {
+	fields, err := valFields(v)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, f := range fields {
+		if f.val.IsUnset() {
+			continue
+		}
+		b, err := opt.JSONMarshal(f.val.boxed())
+		if err != nil {
+			return "", fmt.Errorf("fingerprint field %s: %w", f.name, err)
+		}
+		// Neither names nor JSON contain NUL, so it cleanly separates them.
+		h.Write([]byte(f.name))
+		h.Write([]byte{0})
+		h.Write(b)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FormDiff compares the Val fields of two versions of the same struct (or
+// pointer to struct), such as a form before and after a user edited it.
+// changed holds the new values of fields that became set or were set to a
+// different value, keyed by json name. cleared lists the fields that went
+// from set to unset, in declaration order. Values are compared with
+// reflect.DeepEqual.
+func FormDiff(before, after any) (changed map[string]any, cleared []string, err error) {
+	if bt, at := indirectType(before), indirectType(after); bt != at {
+		return nil, nil, fmt.Errorf("cannot diff fields of %T against %T", before, after)
+	}
+
+	beforeFields, err := valFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := valFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Fields are matched by name as a nil embedded pointer on one side
+	// leaves its promoted fields out.
+	previous := make(map[string]omittable, len(beforeFields))
+	for _, f := range beforeFields {
+		previous[f.name] = f.val
+	}
+
+	changed = map[string]any{}
+	seen := make(map[string]struct{}, len(afterFields))
+	for _, f := range afterFields {
+		seen[f.name] = struct{}{}
+		prev, ok := previous[f.name]
+		wasSet := ok && !prev.IsUnset()
+
+		switch {
+		case !f.val.IsUnset():
+			if !wasSet || !reflect.DeepEqual(prev.boxed(), f.val.boxed()) {
+				changed[f.name] = f.val.boxed()
+			}
+		case wasSet:
+			cleared = append(cleared, f.name)
+		}
+	}
+	for _, f := range beforeFields {
+		if _, ok := seen[f.name]; !ok && !f.val.IsUnset() {
+			cleared = append(cleared, f.name)
+		}
+	}
+
+	return changed, cleared, nil
+}
+
+func indirectType(v any) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Transform applies fn in place to the value of every set Val[T] field in the
+// struct pointed to by v, such as for normalizing input. Unset fields and
+// fields of any other type are left alone. Unlike the other field helpers tags
+// play no part, fields excluded from json with "-" are transformed too.
+func Transform[T any](v any, fn func(T) T) error {
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Pointer {
+		return fmt.Errorf("cannot transform fields of non-pointer type %T", v)
+	}
+
+	// Without any tags every field is named by its Go name, so none are
+	// excluded.
+	fields, err := valFields(v, WithTags())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if val, ok := f.value.Addr().Interface().(*Val[T]); ok && val.state == StateSet {
+			val.value = fn(val.value)
+		}
+	}
+	return nil
+}
+
+// TransformStrings is Transform for Val[string] fields, for example:
+//
+//	err := omit.TransformStrings(&req, strings.TrimSpace)
+func TransformStrings(v any, fn func(string) string) error {
+	return Transform(v, fn)
+}
+
+// jsonNames collects the json names of all fields in the struct type t,
+// including those promoted from untagged embedded structs.
+func jsonNames(t reflect.Type) []string {
+	tags := []string{"json"}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if ft, ok := embeddedStruct(field, tags); ok {
+			names = append(names, jsonNames(ft)...)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name := tagName(field, tags...); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
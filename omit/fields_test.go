@@ -0,0 +1,374 @@
+package omit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fieldsEmbedded struct {
+	Nickname Val[string] `json:"nickname"`
+}
+
+type fieldsStruct struct {
+	fieldsEmbedded
+
+	Name    Val[string] `json:"name"`
+	Age     Val[int]    `json:"age,omitzero"`
+	Email   Val[string]
+	Ignored Val[string] `json:"-"`
+	Plain   string      `json:"plain"`
+
+	hidden Val[string]
+}
+
+func TestSetFields(t *testing.T) {
+	t.Parallel()
+
+	v := fieldsStruct{
+		fieldsEmbedded: fieldsEmbedded{Nickname: From("nick")},
+		Name:           From("hello"),
+		Ignored:        From("ignored"),
+		Plain:          "plain",
+		hidden:         From("hidden"),
+	}
+
+	set, err := SetFields(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 2 {
+		t.Error("wrong fields:", set)
+	}
+	for _, name := range []string{"name", "nickname"} {
+		if _, ok := set[name]; !ok {
+			t.Error("missing", name)
+		}
+	}
+
+	v.Email.Set("a@b.c")
+	if set, err = SetFields(v); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := set["Email"]; !ok {
+		t.Error("untagged fields should use the field name")
+	}
+
+	if _, err := SetFields(5); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+	if _, err := SetFields((*fieldsStruct)(nil)); err == nil {
+		t.Error("expected an error for a nil pointer")
+	}
+}
+
+func TestUnionFields(t *testing.T) {
+	t.Parallel()
+
+	type other struct {
+		Name  Val[string] `json:"name"`
+		Color Val[string] `json:"color"`
+	}
+
+	union, err := UnionFields(
+		fieldsStruct{Name: From("a")},
+		&fieldsStruct{Age: From(5)},
+		fieldsStruct{},
+		other{Name: From("b"), Color: From("red")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct{}{"name": {}, "age": {}, "color": {}}
+	if !reflect.DeepEqual(union, want) {
+		t.Error("wrong union:", union)
+	}
+
+	if union, err := UnionFields(); err != nil || len(union) != 0 {
+		t.Error("no structs should give an empty union:", union, err)
+	}
+	if _, err := UnionFields(fieldsStruct{}, 5); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
+
+func TestTagName(t *testing.T) {
+	t.Parallel()
+
+	type tagged struct {
+		All      string `json:"json_all" db:"db_all" yaml:"yaml_all"`
+		JSONOnly string `json:"json_only,omitempty"`
+		DBOnly   string `db:"db_only"`
+		YAMLOnly string `yaml:"yaml_only"`
+		Skipped  string `json:"-" db:"db_skipped"`
+		Empty    string `json:",omitempty"`
+		Untagged string
+	}
+
+	tests := []struct {
+		field     string
+		preferred []string
+		want      string
+	}{
+		{"All", []string{"json"}, "json_all"},
+		{"All", []string{"db"}, "db_all"},
+		{"All", []string{"yaml", "json"}, "yaml_all"},
+		{"JSONOnly", []string{"json"}, "json_only"},
+		{"JSONOnly", []string{"db", "json"}, "json_only"},
+		{"DBOnly", []string{"json"}, "DBOnly"},
+		{"DBOnly", []string{"db"}, "db_only"},
+		{"YAMLOnly", []string{"db", "yaml"}, "yaml_only"},
+		{"Skipped", []string{"json"}, ""},
+		{"Skipped", []string{"db", "json"}, "db_skipped"},
+		{"Empty", []string{"json"}, "Empty"},
+		{"Untagged", []string{"json", "db", "yaml"}, "Untagged"},
+		{"Untagged", nil, "Untagged"},
+	}
+
+	typ := reflect.TypeFor[tagged]()
+	for _, test := range tests {
+		field, _ := typ.FieldByName(test.field)
+		if got := tagName(field, test.preferred...); got != test.want {
+			t.Errorf("%s %v: want %q, got %q", test.field, test.preferred, test.want, got)
+		}
+	}
+}
+
+func TestSetFieldsWithTags(t *testing.T) {
+	t.Parallel()
+
+	type row struct {
+		ID   Val[int]    `json:"id" db:"user_id"`
+		Name Val[string] `json:"name" db:"-"`
+	}
+
+	set, err := SetFields(row{ID: From(1), Name: From("hello")}, WithTags("db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 1 {
+		t.Error("wrong fields:", set)
+	}
+	if _, ok := set["user_id"]; !ok {
+		t.Error("expected the db tag name")
+	}
+}
+
+func TestIterFields(t *testing.T) {
+	t.Parallel()
+
+	v := fieldsStruct{
+		fieldsEmbedded: fieldsEmbedded{Nickname: From("nick")},
+		Name:           From("hello"),
+		Age:            From(5),
+	}
+
+	var names []string
+	var vals []any
+	for name, val := range IterFields(&v) {
+		names = append(names, name)
+		vals = append(vals, val)
+	}
+
+	if !reflect.DeepEqual(names, []string{"nickname", "name", "age"}) {
+		t.Error("wrong names:", names)
+	}
+	if !reflect.DeepEqual(vals, []any{"nick", "hello", 5}) {
+		t.Error("wrong values:", vals)
+	}
+
+	count := 0
+	for range IterFields(v) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Error("iteration should stop when asked")
+	}
+
+	for range IterFields(5) {
+		t.Error("non-structs should yield nothing")
+	}
+}
+
+func TestOrderedFields(t *testing.T) {
+	t.Parallel()
+
+	v := fieldsStruct{
+		fieldsEmbedded: fieldsEmbedded{Nickname: From("nick")},
+		Age:            From(5),
+		Email:          From("a@b.c"),
+		Ignored:        From("ignored"),
+	}
+
+	fields, err := OrderedFields(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	var values []any
+	for _, f := range fields {
+		keys = append(keys, f.Key)
+		values = append(values, f.Value)
+	}
+	if !reflect.DeepEqual(keys, []string{"nickname", "age", "Email"}) {
+		t.Error("wrong keys:", keys)
+	}
+	if !reflect.DeepEqual(values, []any{"nick", 5, "a@b.c"}) {
+		t.Error("wrong values:", values)
+	}
+
+	if _, err := OrderedFields(5); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
+
+func TestWouldOmit(t *testing.T) {
+	t.Parallel()
+
+	type payload struct {
+		Name  Val[string] `json:"name"`
+		Age   Val[int]    `json:"age"`
+		Tags  Val[[]int]  `json:"tags"`
+		Plain string      `json:"plain"`
+	}
+
+	names, err := WouldOmit(payload{Name: From("hello"), Tags: From[[]int](nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"age", "tags"}) {
+		t.Error("wrong names:", names)
+	}
+
+	names, err = WouldOmit(&payload{Name: From(""), Age: From(0), Tags: From([]int{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 {
+		t.Error("set values should not be omitted:", names)
+	}
+
+	if _, err := WouldOmit("hello"); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	a := fieldsStruct{Name: From("hello"), Age: From(5)}
+	b := fieldsStruct{Name: From("hello"), Age: From(5), Plain: "different"}
+
+	fa, err := Fingerprint(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb, err := Fingerprint(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fa != fb {
+		t.Error("same set fields should share a fingerprint")
+	}
+	if again, _ := Fingerprint(a); again != fa {
+		t.Error("fingerprint should be stable")
+	}
+
+	b.Age = From(6)
+	if fb, _ = Fingerprint(b); fb == fa {
+		t.Error("changing a set value should change the fingerprint")
+	}
+
+	// The same value in a different field is a different filter.
+	c := fieldsStruct{Name: From("x")}
+	d := fieldsStruct{Email: From("x")}
+	fc, _ := Fingerprint(c)
+	fd, _ := Fingerprint(d)
+	if fc == fd {
+		t.Error("field names should be part of the fingerprint")
+	}
+
+	fe, _ := Fingerprint(fieldsStruct{})
+	if fe == fa {
+		t.Error("an empty struct should have its own fingerprint")
+	}
+}
+
+func TestFormDiff(t *testing.T) {
+	t.Parallel()
+
+	before := fieldsStruct{
+		fieldsEmbedded: fieldsEmbedded{Nickname: From("nick")},
+		Name:           From("hello"),
+		Email:          From("a@example.com"),
+	}
+	after := fieldsStruct{
+		fieldsEmbedded: fieldsEmbedded{Nickname: From("nick")},
+		Name:           From("goodbye"),
+		Age:            From(5),
+		Plain:          "ignored",
+	}
+
+	changed, cleared, err := FormDiff(before, &after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"name": "goodbye", "age": 5}
+	if !reflect.DeepEqual(changed, want) {
+		t.Error("wrong changed fields:", changed)
+	}
+	if !reflect.DeepEqual(cleared, []string{"Email"}) {
+		t.Error("wrong cleared fields:", cleared)
+	}
+
+	changed, cleared, err = FormDiff(before, before)
+	if err != nil || len(changed) != 0 || len(cleared) != 0 {
+		t.Error("identical structs should have no differences:", changed, cleared, err)
+	}
+
+	if _, _, err := FormDiff(before, fieldsEmbedded{}); err == nil {
+		t.Error("expected an error for different types")
+	}
+}
+
+func TestTransformStrings(t *testing.T) {
+	t.Parallel()
+
+	v := fieldsStruct{
+		fieldsEmbedded: fieldsEmbedded{Nickname: From("  nick ")},
+		Name:           From(" hello\t"),
+		Age:            From(5),
+		Ignored:        From(" ignored "),
+		Plain:          " plain ",
+	}
+
+	if err := TransformStrings(&v, strings.TrimSpace); err != nil {
+		t.Fatal(err)
+	}
+	if v.Nickname.MustGet() != "nick" {
+		t.Errorf("wrong nickname: %q", v.Nickname.MustGet())
+	}
+	if v.Name.MustGet() != "hello" {
+		t.Errorf("wrong name: %q", v.Name.MustGet())
+	}
+	if v.Ignored.MustGet() != "ignored" {
+		t.Errorf("wrong ignored: %q", v.Ignored.MustGet())
+	}
+	checkState(t, v.Email, StateUnset)
+	if v.Age.MustGet() != 5 || v.Plain != " plain " {
+		t.Error("other fields should not change")
+	}
+
+	if err := Transform(&v, func(i int) int { return i * 2 }); err != nil {
+		t.Fatal(err)
+	}
+	if v.Age.MustGet() != 10 {
+		t.Error("wrong age:", v.Age.MustGet())
+	}
+
+	if err := TransformStrings(v, strings.TrimSpace); err == nil {
+		t.Error("expected an error for a non-pointer")
+	}
+}
@@ -0,0 +1,49 @@
+package omit
+
+import (
+	"math"
+	"net"
+	"testing"
+)
+
+func FuzzTextRoundTrip(f *testing.F) {
+	f.Add(int64(0), 0.0, false, "hello", []byte{1, 1, 1, 1})
+	f.Add(int64(-1), math.Pi, true, "null", []byte(net.IPv6loopback))
+	f.Add(int64(math.MaxInt64), math.SmallestNonzeroFloat64, true, " ", []byte{})
+	f.Add(int64(math.MinInt64), -math.MaxFloat64, false, "1e9", []byte{10, 0, 0})
+
+	f.Fuzz(func(t *testing.T, i int64, fl float64, b bool, s string, ip []byte) {
+		checkTextRoundTrip(t, i, func(a, b int64) bool { return a == b })
+		checkTextRoundTrip(t, fl, func(a, b float64) bool {
+			return math.Float64bits(a) == math.Float64bits(b) || (math.IsNaN(a) && math.IsNaN(b))
+		})
+		checkTextRoundTrip(t, b, func(a, b bool) bool { return a == b })
+
+		// The empty string is the text encoding of unset and so can't
+		// round trip as a set value, see UnmarshalText.
+		if s != "" {
+			checkTextRoundTrip(t, s, func(a, b string) bool { return a == b })
+		}
+
+		if len(ip) == net.IPv4len || len(ip) == net.IPv6len {
+			checkTextRoundTrip(t, net.IP(ip), net.IP.Equal)
+		}
+	})
+}
+
+func checkTextRoundTrip[T any](t *testing.T, want T, equal func(a, b T) bool) {
+	t.Helper()
+
+	text, err := From(want).MarshalText()
+	if err != nil {
+		t.Fatalf("marshal %T %v: %v", want, want, err)
+	}
+
+	var got Val[T]
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatalf("unmarshal %T %q: %v", want, text, err)
+	}
+	if !got.IsValue() || !equal(got.MustGet(), want) {
+		t.Errorf("%T %v did not round trip through %q, got: %v (%s)", want, want, text, got.GetOrZero(), got.State())
+	}
+}
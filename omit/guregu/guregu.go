@@ -0,0 +1,77 @@
+// Package guregu converts between omit.Val and the types of the
+// github.com/guregu/null package, easing migration between the two. An
+// invalid guregu value is treated as unset and vice versa.
+//
+// github.com/guregu/null is required by this module, so it is part of the
+// module graph of every user. Only programs importing this package compile it
+// in, however.
+package guregu
+
+import (
+	"time"
+
+	"github.com/guregu/null/v5"
+
+	"github.com/blink-io/opt/omit"
+)
+
+// ToString converts v to a null.String, unset becomes invalid.
+func ToString(v omit.Val[string]) null.String {
+	return null.NewString(v.Get())
+}
+
+// FromString converts s to a value, invalid becomes unset.
+func FromString(s null.String) omit.Val[string] {
+	return omit.FromCond(s.String, s.Valid)
+}
+
+// ToInt converts v to a null.Int, unset becomes invalid.
+func ToInt(v omit.Val[int64]) null.Int {
+	return null.NewInt(v.Get())
+}
+
+// FromInt converts i to a value, invalid becomes unset.
+func FromInt(i null.Int) omit.Val[int64] {
+	return omit.FromCond(i.Int64, i.Valid)
+}
+
+// ToFloat converts v to a null.Float, unset becomes invalid.
+func ToFloat(v omit.Val[float64]) null.Float {
+	return null.NewFloat(v.Get())
+}
+
+// FromFloat converts f to a value, invalid becomes unset.
+func FromFloat(f null.Float) omit.Val[float64] {
+	return omit.FromCond(f.Float64, f.Valid)
+}
+
+// ToBool converts v to a null.Bool, unset becomes invalid.
+func ToBool(v omit.Val[bool]) null.Bool {
+	return null.NewBool(v.Get())
+}
+
+// FromBool converts b to a value, invalid becomes unset.
+func FromBool(b null.Bool) omit.Val[bool] {
+	return omit.FromCond(b.Bool, b.Valid)
+}
+
+// ToTime converts v to a null.Time, unset becomes invalid.
+func ToTime(v omit.Val[time.Time]) null.Time {
+	return null.NewTime(v.Get())
+}
+
+// FromTime converts t to a value, invalid becomes unset.
+func FromTime(t null.Time) omit.Val[time.Time] {
+	return omit.FromCond(t.Time, t.Valid)
+}
+
+// ToValue converts v to the generic null.Value, unset becomes invalid.
+func ToValue[T any](v omit.Val[T]) null.Value[T] {
+	return null.NewValue(v.Get())
+}
+
+// FromValue converts the generic null.Value to a value, invalid becomes
+// unset.
+func FromValue[T any](v null.Value[T]) omit.Val[T] {
+	return omit.FromCond(v.V, v.Valid)
+}
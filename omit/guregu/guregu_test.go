@@ -0,0 +1,91 @@
+package guregu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guregu/null/v5"
+
+	"github.com/blink-io/opt/omit"
+)
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	if s := ToString(omit.From("hello")); !s.Valid || s.String != "hello" {
+		t.Error("wrong value:", s)
+	}
+	if s := ToString(omit.Val[string]{}); s.Valid {
+		t.Error("unset should be invalid")
+	}
+
+	if v := FromString(null.StringFrom("hello")); v.MustGet() != "hello" {
+		t.Error("wrong value:", v)
+	}
+	if v := FromString(null.String{}); !v.IsUnset() {
+		t.Error("invalid should be unset")
+	}
+}
+
+func TestInt(t *testing.T) {
+	t.Parallel()
+
+	if i := ToInt(omit.From[int64](5)); !i.Valid || i.Int64 != 5 {
+		t.Error("wrong value:", i)
+	}
+	if i := ToInt(omit.Val[int64]{}); i.Valid {
+		t.Error("unset should be invalid")
+	}
+
+	if v := FromInt(null.IntFrom(5)); v.MustGet() != 5 {
+		t.Error("wrong value:", v)
+	}
+	if v := FromInt(null.Int{}); !v.IsUnset() {
+		t.Error("invalid should be unset")
+	}
+}
+
+func TestFloat(t *testing.T) {
+	t.Parallel()
+
+	if f := ToFloat(omit.From(1.5)); !f.Valid || f.Float64 != 1.5 {
+		t.Error("wrong value:", f)
+	}
+	if v := FromFloat(null.Float{}); !v.IsUnset() {
+		t.Error("invalid should be unset")
+	}
+}
+
+func TestBool(t *testing.T) {
+	t.Parallel()
+
+	if b := ToBool(omit.From(true)); !b.Valid || !b.Bool {
+		t.Error("wrong value:", b)
+	}
+	if v := FromBool(null.BoolFrom(false)); v.IsUnset() || v.MustGet() {
+		t.Error("wrong value:", v)
+	}
+}
+
+func TestTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	if v := FromTime(ToTime(omit.From(now))); !v.MustGet().Equal(now) {
+		t.Error("round trip failed:", v)
+	}
+	if tm := ToTime(omit.Val[time.Time]{}); tm.Valid {
+		t.Error("unset should be invalid")
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	if v := ToValue(omit.From[uint8](5)); !v.Valid || v.V != 5 {
+		t.Error("wrong value:", v)
+	}
+	if v := FromValue(null.Value[uint8]{}); !v.IsUnset() {
+		t.Error("invalid should be unset")
+	}
+}
@@ -0,0 +1,51 @@
+package omit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blink-io/opt"
+)
+
+// jsonPatchOp is a single RFC 6902 operation.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// pointerEscaper escapes a member name for use in an RFC 6901 JSON Pointer.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// JSONPatch builds an RFC 6902 JSON Patch document from the set Val fields of
+// the struct (or pointer to struct) v, with one operation per field in
+// declaration order and unset fields left out. Each is an "add" operation on
+// the path of the field's json name, which per the RFC replaces the member if
+// it already exists and creates it otherwise. Values are written whole, so a
+// struct payload replaces the target rather than being patched into it. With
+// no set fields the result is an empty patch, [].
+func JSONPatch(v any) ([]byte, error) {
+	fields, err := valFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]jsonPatchOp, 0, len(fields))
+	for _, f := range fields {
+		if f.val.IsUnset() {
+			continue
+		}
+		b, err := opt.JSONMarshal(f.value.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", f.name, err)
+		}
+		ops = append(ops, jsonPatchOp{
+			Op:    "add",
+			Path:  "/" + pointerEscaper.Replace(f.name),
+			Value: b,
+		})
+	}
+
+	return opt.JSONMarshal(ops)
+}
@@ -0,0 +1,49 @@
+package omit
+
+import (
+	"testing"
+)
+
+func TestJSONPatch(t *testing.T) {
+	t.Parallel()
+
+	type address struct {
+		City string `json:"city"`
+	}
+	type patchSource struct {
+		Name    Val[string]  `json:"name"`
+		Age     Val[int]     `json:"age"`
+		Email   Val[string]  `json:"email"`
+		Address Val[address] `json:"address"`
+		Odd     Val[bool]    `json:"a/b~c"`
+		Plain   string       `json:"plain"`
+	}
+
+	b, err := JSONPatch(patchSource{
+		Name:    From("hello"),
+		Address: From(address{City: "Paris"}),
+		Odd:     From(true),
+		Plain:   "ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"add","path":"/name","value":"hello"},` +
+		`{"op":"add","path":"/address","value":{"city":"Paris"}},` +
+		`{"op":"add","path":"/a~1b~0c","value":true}]`
+	if string(b) != want {
+		t.Error("wrong patch:", string(b))
+	}
+
+	b, err = JSONPatch(&patchSource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `[]` {
+		t.Error("expected an empty patch:", string(b))
+	}
+
+	if _, err := JSONPatch(5); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
@@ -0,0 +1,106 @@
+package omit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/blink-io/opt"
+	"github.com/blink-io/opt/internal/globaldata"
+)
+
+// MergePatchOptions alter how ApplyMergePatchWith applies a patch. The zero
+// value gives the behavior of ApplyMergePatch.
+type MergePatchOptions struct {
+	// NullUnsets makes a patch member that is null unset its field. RFC 7396
+	// uses null to mean "remove", but as an omit value cannot hold a null
+	// this is an error by default.
+	NullUnsets bool
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) document to the Val
+// fields of dst, which must be a pointer to a struct. Members present in the
+// patch set the matching field (found by json name, case-insensitively like
+// the json package) and fields that are absent from the patch are left as
+// they are. A null member is an error, see ApplyMergePatchWith for unsetting
+// the field instead.
+//
+// Only Val fields are patched, other fields and unknown members are ignored.
+// A member is decoded into its field whole, nested objects replace the
+// field's value rather than being merged into it. The patch is applied to a
+// copy of *dst which is only stored back if every member applied, so dst is
+// left untouched when an error is returned.
+func ApplyMergePatch(dst any, patch []byte) error {
+	return ApplyMergePatchWith(dst, patch, MergePatchOptions{})
+}
+
+// ApplyMergePatchWith is ApplyMergePatch with explicit MergePatchOptions.
+func ApplyMergePatchWith(dst any, patch []byte, opts MergePatchOptions) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer {
+		return fmt.Errorf("cannot apply merge patch to non-pointer %T", dst)
+	}
+	if _, err := valFields(dst); err != nil {
+		return err
+	}
+
+	var members map[string]json.RawMessage
+	if err := opt.JSONUnmarshal(patch, &members); err != nil {
+		return err
+	}
+	if members == nil {
+		return errors.New("merge patch must be a json object")
+	}
+
+	for dv.Kind() == reflect.Pointer {
+		dv = dv.Elem()
+	}
+	patched := reflect.New(dv.Type())
+	patched.Elem().Set(dv)
+
+	fields, err := valFields(patched.Interface())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		raw, ok := mergePatchMember(members, f.name)
+		if !ok {
+			continue
+		}
+
+		if bytes.Equal(raw, globaldata.JSONNull) {
+			if !opts.NullUnsets {
+				return fmt.Errorf("cannot apply null to omit value field %q", f.name)
+			}
+			f.ptr().Unset()
+			continue
+		}
+
+		// Unset first so the member replaces the value, and is never
+		// decoded into a payload that the copy shares with dst.
+		f.ptr().Unset()
+		if err := f.ptr().UnmarshalJSON(raw); err != nil {
+			return fmt.Errorf("field %q: %w", f.name, err)
+		}
+	}
+
+	dv.Set(patched.Elem())
+	return nil
+}
+
+// mergePatchMember finds the member for name, preferring an exact match.
+func mergePatchMember(members map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := members[name]; ok {
+		return raw, true
+	}
+	for key, raw := range members {
+		if strings.EqualFold(key, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,101 @@
+package omit
+
+import (
+	"slices"
+	"testing"
+)
+
+type mergePatchTarget struct {
+	Name  Val[string] `json:"name"`
+	Age   Val[int]    `json:"age"`
+	Email Val[string] `json:"email"`
+	Plain string      `json:"plain"`
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	t.Parallel()
+
+	target := mergePatchTarget{
+		Name:  From("old"),
+		Email: From("old@example.com"),
+	}
+
+	patch := []byte(`{"name":"new","AGE":5,"plain":"ignored","unknown":true}`)
+	if err := ApplyMergePatch(&target, patch); err != nil {
+		t.Fatal(err)
+	}
+
+	if target.Name.MustGet() != "new" {
+		t.Error("name should be patched")
+	}
+	if target.Age.MustGet() != 5 {
+		t.Error("age should be patched")
+	}
+	if target.Email.MustGet() != "old@example.com" {
+		t.Error("email was absent and should be untouched")
+	}
+	if target.Plain != "" {
+		t.Error("non-Val fields should be ignored")
+	}
+
+	if err := ApplyMergePatch(&target, []byte(`{"age":"five"}`)); err == nil {
+		t.Error("expected a type error")
+	}
+	if err := ApplyMergePatch(&target, []byte(`[1]`)); err == nil {
+		t.Error("expected an error for a non-object patch")
+	}
+	if err := ApplyMergePatch(&target, []byte(`null`)); err == nil {
+		t.Error("expected an error for a null patch")
+	}
+	if err := ApplyMergePatch(target, []byte(`{}`)); err == nil {
+		t.Error("expected an error for a non-pointer")
+	}
+}
+
+func TestApplyMergePatchNull(t *testing.T) {
+	t.Parallel()
+
+	target := mergePatchTarget{Name: From("old"), Age: From(5)}
+
+	if err := ApplyMergePatch(&target, []byte(`{"name":null}`)); err == nil {
+		t.Error("null should be an error by default")
+	}
+
+	if err := ApplyMergePatchWith(&target, []byte(`{"name":null}`), MergePatchOptions{NullUnsets: true}); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, target.Name, StateUnset)
+	checkState(t, target.Age, StateSet)
+}
+
+func TestApplyMergePatchFailureUnchanged(t *testing.T) {
+	t.Parallel()
+
+	type target struct {
+		Name Val[string]         `json:"name"`
+		Tags Val[[]string]       `json:"tags"`
+		Meta Val[map[string]int] `json:"meta"`
+		Age  Val[int]            `json:"age"`
+	}
+
+	tags := []string{"a", "b"}
+	got := target{Name: From("old"), Tags: From(tags), Meta: From(map[string]int{"x": 1})}
+	patch := []byte(`{"name":"new","tags":["c","d"],"meta":{"y":2},"age":"five"}`)
+	if err := ApplyMergePatch(&got, patch); err == nil {
+		t.Fatal("expected a type error")
+	}
+	if got.Name.MustGet() != "old" || !slices.Equal(got.Tags.MustGet(), []string{"a", "b"}) ||
+		len(got.Meta.MustGet()) != 1 || got.Meta.MustGet()["x"] != 1 || !got.Age.IsUnset() {
+		t.Error("a failed patch should leave dst untouched:", got)
+	}
+	if !slices.Equal(tags, []string{"a", "b"}) {
+		t.Error("a failed patch should not write into the payloads of dst:", tags)
+	}
+
+	if err := ApplyMergePatch(&got, []byte(`{"meta":{"y":2}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if m := got.Meta.MustGet(); len(m) != 1 || m["y"] != 2 {
+		t.Error("a member should replace the value rather than merge into it:", m)
+	}
+}
@@ -4,10 +4,21 @@ package omit
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"crypto/subtle"
 	"database/sql/driver"
 	"encoding"
+	"encoding/gob"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
 	"reflect"
+	"time"
 
 	"github.com/blink-io/opt"
 	"github.com/blink-io/opt/internal/globaldata"
@@ -35,6 +46,11 @@ func (s state) String() string {
 
 // Val allows representing a value with a state of "unset" or "set".
 // Its zero value is useful and initially "unset".
+//
+// Like other plain Go values a Val is safe to read from many goroutines at
+// once, but not to change (with Set, Unset, UnmarshalJSON and the like) while
+// it may be read or changed elsewhere. Use a SyncVal for values that are
+// shared and changed.
 type Val[T any] struct {
 	value T
 	state state
@@ -60,6 +76,15 @@ func FromPtr[T any](val *T) Val[T] {
 	}
 }
 
+// FromPtrRequired is FromPtr for pointers that must not be nil, it returns an
+// error rather than an unset value for a nil pointer.
+func FromPtrRequired[T any](val *T) (Val[T], error) {
+	if val == nil {
+		return Val[T]{}, errors.New("cannot create omit value from nil pointer")
+	}
+	return From(*val), nil
+}
+
 // FromCond conditionally creates a 'set' value if the bool is true, else
 // it will return an omitted value.
 func FromCond[T any](val T, ok bool) Val[T] {
@@ -72,6 +97,59 @@ func FromCond[T any](val T, ok bool) Val[T] {
 	}
 }
 
+// FromValid creates a 'set' value if validate accepts val. If it does not
+// the value is unset and the validation error is returned, this is FromCond
+// that carries the reason.
+func FromValid[T any](val T, validate func(T) error) (Val[T], error) {
+	if err := validate(val); err != nil {
+		return Val[T]{}, err
+	}
+	return From(val), nil
+}
+
+// MustFromValid is FromValid that panics with the validation error, for
+// package level defaults that are known to be good.
+func MustFromValid[T any](val T, validate func(T) error) Val[T] {
+	v, err := FromValid(val, validate)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// FromFloat creates a value from f, it is unset if f is NaN or infinite so
+// that invalid results of float math become no value.
+func FromFloat(f float64) Val[float64] {
+	return FromCond(f, !math.IsNaN(f) && !math.IsInf(f, 0))
+}
+
+// FromDeadline creates a value from the deadline of ctx, it is unset if ctx
+// has no deadline.
+func FromDeadline(ctx context.Context) Val[time.Time] {
+	return FromCond(ctx.Deadline())
+}
+
+// FromRetry calls fn up to attempts times, returning a set value with the
+// result of the first call that succeeds. It is unset if every attempt fails,
+// the errors are discarded as this is meant for best-effort fetches. There is
+// no delay between attempts.
+func FromRetry[T any](attempts int, fn func() (T, error)) Val[T] {
+	for range attempts {
+		if val, err := fn(); err == nil {
+			return From(val)
+		}
+	}
+	return Val[T]{}
+}
+
+// Import creates a value from the plain representation produced by Export.
+func Import[T any](e struct {
+	Value T
+	Set   bool
+}) Val[T] {
+	return FromCond(e.Value, e.Set)
+}
+
 // Get the underlying value, if one exists.
 func (v Val[T]) Get() (T, bool) {
 	if v.state == StateSet {
@@ -90,6 +168,15 @@ func (v Val[T]) GetOr(fallback T) T {
 	return fallback
 }
 
+// GetOrFunc gets the value or returns the result of fn if the value does not
+// exist. Unlike GetOr the fallback is only computed when it is needed.
+func (v Val[T]) GetOrFunc(fn func() T) T {
+	if v.state == StateSet {
+		return v.value
+	}
+	return fn()
+}
+
 // GetOrZero returns the zero value for T if the value was omitted.
 func (v Val[T]) GetOrZero() T {
 	if v.state != StateSet {
@@ -99,16 +186,76 @@ func (v Val[T]) GetOrZero() T {
 	return v.value
 }
 
-// MustGet retrieves the value or panics if it's null
+// GetOrErr returns the value, or the zero value and err if it is unset. It
+// is for code where a missing value is an error:
+//
+//	id, err := req.ID.GetOrErr(errMissingID)
+func (v Val[T]) GetOrErr(err error) (T, error) {
+	if v.state != StateSet {
+		var t T
+		return t, err
+	}
+	return v.value, nil
+}
+
+// GetOrErrf is GetOrErr with an error made by fmt.Errorf, which is only
+// called if the value is unset.
+func (v Val[T]) GetOrErrf(format string, args ...any) (T, error) {
+	if v.state != StateSet {
+		var t T
+		return t, fmt.Errorf(format, args...)
+	}
+	return v.value, nil
+}
+
+// GetOrZeroLog is GetOrZero but calls onMiss when the value was omitted,
+// which is handy to instrument where defaults end up being used.
+func (v Val[T]) GetOrZeroLog(onMiss func()) T {
+	if v.state != StateSet {
+		if onMiss != nil {
+			onMiss()
+		}
+		var t T
+		return t
+	}
+	return v.value
+}
+
+// ErrNoValue is what MustGet panics with when the value is unset.
+var ErrNoValue = errors.New("no value present")
+
+// MustGet retrieves the value or panics with ErrNoValue if it's unset
 func (v Val[T]) MustGet() T {
 	val, ok := v.Get()
 	if !ok {
-		panic("no value present")
+		panic(ErrNoValue)
 	}
 
 	return val
 }
 
+// Recover runs fn and returns ErrNoValue if it panics because of a MustGet on
+// an unset value, so a run of MustGet calls can be guarded all at once. Any
+// other panic is passed through.
+//
+//	err := omit.Recover(func() {
+//		addr = req.Host.MustGet() + ":" + req.Port.MustGet()
+//	})
+func Recover(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok && errors.Is(e, ErrNoValue) {
+				err = e
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	fn()
+	return nil
+}
+
 // Or returns v or other depending on their states. In general
 // set > unset and therefore the one with the state highest in that
 // area will win out.
@@ -127,6 +274,28 @@ func (v Val[T]) Or(other Val[T]) Val[T] {
 	}
 }
 
+// OrElse is a lazy Or, fn is only called to build the fallback when v is not
+// set.
+func (v Val[T]) OrElse(fn func() Val[T]) Val[T] {
+	if v.state == StateSet {
+		return v
+	}
+	return v.Or(fn())
+}
+
+// Coalesce returns the first set value in vals, like the SQL function of the
+// same name, or an unset value if none are set.
+func Coalesce[T any](vals ...Val[T]) Val[T] {
+	var result Val[T]
+	for _, v := range vals {
+		if v.state == StateSet {
+			return v
+		}
+		result = result.Or(v)
+	}
+	return result
+}
+
 // Map transforms the value inside if it is set, else it returns a value of the
 // same state.
 //
@@ -140,6 +309,15 @@ func (v Val[T]) Map(fn func(T) T) Val[T] {
 	return Val[T]{state: v.state}
 }
 
+// Filter returns v if it is unset or keep returns true for its value, and an
+// unset value otherwise. keep is never called on an unset value.
+func (v Val[T]) Filter(keep func(T) bool) Val[T] {
+	if v.state == StateSet && !keep(v.value) {
+		return Val[T]{}
+	}
+	return v
+}
+
 // Map transforms the value inside if it is set, else it returns value of the
 // same state.
 func Map[A any, B any](v Val[A], fn func(A) B) Val[B] {
@@ -149,12 +327,132 @@ func Map[A any, B any](v Val[A], fn func(A) B) Val[B] {
 	return Val[B]{state: v.state}
 }
 
+// FlatMap is Map for a transform that itself returns an optional value, it
+// avoids ending up with a Val[Val[B]]. It short-circuits: fn is only called
+// when v is set, otherwise an unset value is returned without calling fn.
+// When fn is called its result is returned as-is.
+func FlatMap[A any, B any](v Val[A], fn func(A) Val[B]) Val[B] {
+	if v.state == StateSet {
+		return fn(v.value)
+	}
+	return Val[B]{state: v.state}
+}
+
+// Zip pairs the values of a and b, the result is set only if both are.
+func Zip[A any, B any](a Val[A], b Val[B]) Val[struct {
+	A A
+	B B
+}] {
+	return ZipWith(a, b, func(a A, b B) struct {
+		A A
+		B B
+	} {
+		return struct {
+			A A
+			B B
+		}{A: a, B: b}
+	})
+}
+
+// ZipWith combines the values of a and b with fn, the result is set only if
+// both are. fn is not called otherwise.
+func ZipWith[A any, B any, C any](a Val[A], b Val[B], fn func(A, B) C) Val[C] {
+	if a.state != StateSet || b.state != StateSet {
+		return Val[C]{}
+	}
+	return From(fn(a.value, b.value))
+}
+
+// AsAny erases the type of v, boxing its value while keeping its state.
+func (v Val[T]) AsAny() Val[any] {
+	if v.state != StateSet {
+		return Val[any]{}
+	}
+	return From[any](v.value)
+}
+
+// Cast recovers a typed value from one erased by AsAny. It returns the typed
+// value and true only if v is set and holds a T. Otherwise the value is unset
+// and the flag is false, use IsUnset on v to tell an unset input apart from a
+// type mismatch.
+func Cast[T any](v Val[any]) (Val[T], bool) {
+	if v.state != StateSet {
+		return Val[T]{}, false
+	}
+	val, ok := v.value.(T)
+	if !ok {
+		return Val[T]{}, false
+	}
+	return From(val), true
+}
+
+// Assert is the type assertion x.(T) as a value, it is set if the assertion
+// succeeds and unset if it fails, including when x is nil.
+func Assert[T any](x any) Val[T] {
+	val, ok := x.(T)
+	return FromCond(val, ok)
+}
+
+// MapLookup translates the value through table. The result is set only if v
+// is set and its value is a key in table.
+func MapLookup[A comparable, B any](v Val[A], table map[A]B) Val[B] {
+	if v.state != StateSet {
+		return Val[B]{}
+	}
+	b, ok := table[v.value]
+	return FromCond(b, ok)
+}
+
+// MergeMap merges two optional maps key by key rather than replacing one
+// with the other. The result is a new map holding the union of the keys of
+// whichever of base and patch are set, with patch winning on conflicts. It is
+// only unset if both are unset. Neither input map is modified.
+func MergeMap[K comparable, V any](base, patch Val[map[K]V]) Val[map[K]V] {
+	if base.state != StateSet && patch.state != StateSet {
+		return Val[map[K]V]{}
+	}
+
+	merged := make(map[K]V, len(base.value)+len(patch.value))
+	maps.Copy(merged, base.value)
+	maps.Copy(merged, patch.value)
+	return From(merged)
+}
+
 // Set the value (and the state to 'set')
 func (v *Val[T]) Set(val T) {
 	v.value = val
 	v.state = StateSet
 }
 
+// SetIfUnset sets the value only if v is unset, leaving an existing value
+// alone. It returns true if the value was set.
+func (v *Val[T]) SetIfUnset(val T) bool {
+	if v.state == StateSet {
+		return false
+	}
+	v.Set(val)
+	return true
+}
+
+// Swap sets the value and returns the previous one and whether it was set,
+// like atomic.Value.Swap but without any synchronization, see SyncVal.Swap
+// for that.
+func (v *Val[T]) Swap(val T) (prev T, wasSet bool) {
+	prev, wasSet = v.Get()
+	v.Set(val)
+	return prev, wasSet
+}
+
+// Replace sets the value only if v is already set, leaving an unset value
+// alone. It returns true if the value was replaced.
+func (v *Val[T]) Replace(val T) bool {
+	if v.state != StateSet {
+		return false
+	}
+	v.value = val
+	return true
+}
+
 // Unset the value (state is set to 'unset')
 func (v *Val[T]) Unset() {
 	var empty T
@@ -162,6 +460,19 @@ func (v *Val[T]) Unset() {
 	v.state = StateUnset
 }
 
+// WithValue returns a set copy of v holding val, the value counterpart of
+// Set for chaining such as cfg.Timeout = base.Timeout.WithValue(30).
+func (v Val[T]) WithValue(val T) Val[T] {
+	v.Set(val)
+	return v
+}
+
+// Cleared returns an unset copy of v, the value counterpart of Unset.
+func (v Val[T]) Cleared() Val[T] {
+	v.Unset()
+	return v
+}
+
 // IsValue returns true if v contains a value (ie. not omitted/unset)
 func (v Val[T]) IsValue() bool {
 	return v.state == StateSet
@@ -172,26 +483,213 @@ func (v Val[T]) IsUnset() bool {
 	return v.state == StateUnset
 }
 
+// AsOption returns a function that calls apply with the value if v is set and
+// does nothing otherwise, for turning optional config into a list of
+// functional options.
+//
+//	opts := []func(){
+//		omit.AsOption(cfg.Timeout, client.SetTimeout),
+//		omit.AsOption(cfg.Retries, client.SetRetries),
+//	}
+func AsOption[T any](v Val[T], apply func(T)) func() {
+	return func() {
+		if v.state == StateSet {
+			apply(v.value)
+		}
+	}
+}
+
+// IsSetAnd returns true if v is set and its value satisfies pred. pred is
+// not called for an unset value.
+func (v Val[T]) IsSetAnd(pred func(T) bool) bool {
+	return v.state == StateSet && pred(v.value)
+}
+
+// Contains returns true if v is set and its value is target.
+func Contains[T comparable](v Val[T], target T) bool {
+	return v.state == StateSet && v.value == target
+}
+
 func (v Val[T]) IfValue(then func(v T)) {
 	if v.state == StateSet && then != nil {
 		then(v.value)
 	}
 }
 
+// MutateIfSet calls fn with a pointer to the stored value if it is set, so
+// that it can be changed in place. Use IfValue to only read the value.
+func (v *Val[T]) MutateIfSet(fn func(*T)) {
+	if v.state == StateSet && fn != nil {
+		fn(&v.value)
+	}
+}
+
 func (v Val[T]) IfUnset(then func()) {
 	if v.state == StateUnset && then != nil {
 		then()
 	}
 }
 
+// Ptr returns a pointer to a copy of the value, or nil if unset. Changes made
+// through the pointer do not affect v. This is the inverse of FromPtr.
+func (v Val[T]) Ptr() *T {
+	if v.state == StateSet {
+		return &v.value
+	}
+	return nil
+}
+
+// GetPtr returns a pointer to the value held inside v and true if it is set,
+// so that large values can be read without copying them. Unlike Ptr this is
+// not a copy: writing through the pointer changes v, and the pointer must
+// not be kept beyond v's own lifetime or after v is reassigned.
+func (v *Val[T]) GetPtr() (*T, bool) {
+	if v.state != StateSet {
+		return nil, false
+	}
+	return &v.value, true
+}
+
+// AsMapEntry returns a map holding just the value under key if it is set, and
+// an empty map if it is not. Useful for assembling update documents.
+func (v Val[T]) AsMapEntry(key string) map[string]T {
+	if v.state != StateSet {
+		return map[string]T{}
+	}
+	return map[string]T{key: v.value}
+}
+
 // State retrieves the internal state, mostly useful for testing.
 func (v Val[T]) State() state {
 	return v.state
 }
 
+// WithState returns a copy of v with its state replaced by s and its payload
+// kept, mostly useful for building particular states in tests. An unset copy
+// still holds the payload, it is just not visible through the getters.
+func (v Val[T]) WithState(s state) Val[T] {
+	v.state = s
+	return v
+}
+
+// Unwrap returns the value if it is set and is an error, and nil otherwise.
+// Go does not allow a method only for Val[error], so it exists on every Val
+// but only does anything useful for error payloads.
+//
+// It lets an error type embed an optional cause and take part in errors.Is
+// and errors.As chains, which stop at an unset cause:
+//
+//	type QueryError struct {
+//		omit.Val[error]
+//		Query string
+//	}
+//
+//	func (e QueryError) Error() string { return "query failed: " + e.Query }
+func (v Val[T]) Unwrap() error {
+	if v.state != StateSet {
+		return nil
+	}
+	err, _ := any(v.value).(error)
+	return err
+}
+
+// String implements fmt.Stringer, an unset value renders as "<unset>" and a
+// set one as fmt.Sprint of its value.
+func (v Val[T]) String() string {
+	if v.state != StateSet {
+		return "<unset>"
+	}
+	return fmt.Sprint(v.value)
+}
+
+// Format implements fmt.Formatter so that values print as their payload
+// rather than as the internals of Val. A set value is formatted with the same
+// verb and flags as v, so %q, %+v, %x and friends apply to the payload, while
+// an unset value prints as <unset> whatever the verb.
+func (v Val[T]) Format(f fmt.State, verb rune) {
+	if v.state != StateSet {
+		_, _ = io.WriteString(f, "<unset>")
+		return
+	}
+	_, _ = fmt.Fprintf(f, fmt.FormatString(f, verb), v.value)
+}
+
+// LogValue implements slog.LogValuer so that values log as their payload, or
+// as "<unset>" when unset, rather than as an opaque struct.
+func (v Val[T]) LogValue() slog.Value {
+	if v.state != StateSet {
+		return slog.StringValue("<unset>")
+	}
+	return slog.AnyValue(v.value)
+}
+
+// Export returns a plain struct representation of the value for use with
+// encoders that know nothing about this package. The reverse is Import.
+func (v Val[T]) Export() struct {
+	Value T
+	Set   bool
+} {
+	return struct {
+		Value T
+		Set   bool
+	}{
+		Value: v.value,
+		Set:   v.state == StateSet,
+	}
+}
+
+// StateByte returns the state as a single byte, 0 for unset and 1 for set,
+// for use as a marker in custom binary formats.
+func (v Val[T]) StateByte() byte {
+	if v.state == StateSet {
+		return 1
+	}
+	return 0
+}
+
+// SetStateByte sets the state from a byte produced by StateByte. Setting the
+// state to unset clears the value, setting it to set keeps the current value.
+func (v *Val[T]) SetStateByte(b byte) error {
+	switch b {
+	case 0:
+		v.Unset()
+	case 1:
+		v.state = StateSet
+	default:
+		return fmt.Errorf("invalid state byte for omit.Val: %d", b)
+	}
+	return nil
+}
+
+// boxed returns the value as an any for the reflection based helpers which
+// cannot name T.
+func (v Val[T]) boxed() any {
+	return v.value
+}
+
 // UnmarshalJSON implements json.Unmarshaler. Notably will fail to unmarshal
-// if given a null.
+// if given a null. It decodes with the zero DecodeOptions, see
+// UnmarshalJSONWith and Decoding for others.
+//
+// Empty (or all whitespace) data makes the value unset. The json package never
+// passes that, but it lets a Val be reset by calling UnmarshalJSON(nil).
+// Whitespace around a null is ignored.
+//
+// A set value is decoded into in place as the json package would, so a slice
+// payload has its backing array reused and a map payload is merged into.
+// Unset values always decode into a fresh T.
 func (v *Val[T]) UnmarshalJSON(data []byte) error {
+	return v.UnmarshalJSONWith(data, DecodeOptions{})
+}
+
+// UnmarshalJSONWith is UnmarshalJSON with explicit DecodeOptions.
+func (v *Val[T]) UnmarshalJSONWith(data []byte, opts DecodeOptions) error {
+	if opts.MaxBytes > 0 && len(data) > opts.MaxBytes {
+		return fmt.Errorf("json input of %d bytes exceeds the limit of %d bytes", len(data), opts.MaxBytes)
+	}
+
+	data = bytes.TrimSpace(data)
+
 	switch {
 	case len(data) == 0:
 		var zero T
@@ -199,9 +697,30 @@ func (v *Val[T]) UnmarshalJSON(data []byte) error {
 		v.state = StateUnset
 		return nil
 	case bytes.Equal(data, globaldata.JSONNull):
-		return errors.New("cannot unmarshal 'null' value into omit value")
+		if !opts.NullAsUnset {
+			return errors.New("cannot unmarshal 'null' value into omit value")
+		}
+		var zero T
+		v.value = zero
+		v.state = StateUnset
+		return nil
+	case opts.EmptyStringAsUnset && reflect.TypeFor[T]().Kind() == reflect.String &&
+		bytes.Equal(data, emptyJSONString):
+		var zero T
+		v.value = zero
+		v.state = StateUnset
+		return nil
 	default:
-		err := opt.JSONUnmarshal(data, &v.value)
+		if opts.RejectDuplicateKeys {
+			if err := checkDuplicateKeys(data); err != nil {
+				return err
+			}
+		}
+		if v.state != StateSet {
+			var zero T
+			v.value = zero
+		}
+		err := opt.UnmarshalValueJSON(data, &v.value)
 		if err != nil {
 			return err
 		}
@@ -221,14 +740,80 @@ func (v *Val[T]) UnmarshalJSON(data []byte) error {
 // supported.
 //
 // For a package that works well with this package see github.com/aarondl/json.
+//
+// Unset values that are not omitted marshal as null. See Sentinel for writing
+// something else instead, and MarshalJSONStrict and Strict for making that an
+// error.
 func (v Val[T]) MarshalJSON() ([]byte, error) {
-	switch v.state {
-	case StateSet:
+	return v.MarshalJSONSentinel(globaldata.JSONNull)
+}
 
-		return opt.JSONMarshal(v.value)
-	default:
-		return globaldata.JSONNull, nil
+// ErrUnsetJSON is returned by MarshalJSONStrict for an unset value.
+var ErrUnsetJSON = errors.New("unset omit value cannot be represented in standard JSON; use aarondl/json")
+
+// MarshalJSONStrict is MarshalJSON except that an unset value is an
+// ErrUnsetJSON error rather than being written as null. The null written
+// by default cannot be read back by UnmarshalJSON, so this catches encoders
+// that do not omit unset values early.
+func (v Val[T]) MarshalJSONStrict() ([]byte, error) {
+	if v.state != StateSet {
+		return nil, ErrUnsetJSON
 	}
+	return opt.MarshalValueJSON(v.value)
+}
+
+// Strict is a Val that marshals with MarshalJSONStrict, for struct fields that
+// must be omitted by the encoder (such as with an `omitzero` tag) whenever
+// they are unset. All the other methods of Val are available on it.
+type Strict[T any] struct {
+	Val[T]
+}
+
+// MarshalJSON implements json.Marshaler with MarshalJSONStrict.
+func (s Strict[T]) MarshalJSON() ([]byte, error) {
+	return s.Val.MarshalJSONStrict()
+}
+
+// MarshalJSONSentinel is MarshalJSON with an explicit sentinel to write for
+// an unset value, which must be valid JSON such as []byte(`"__unset__"`) for
+// consumers that need to see a marker.
+//
+// Encoders that omit unset values (github.com/aarondl/json, or the std library
+// with an `omitzero` tag) never call MarshalJSON for them, so the sentinel
+// only shows up where an unset value is not omitted, such as in fields
+// without those tags or in slices.
+func (v Val[T]) MarshalJSONSentinel(sentinel []byte) ([]byte, error) {
+	if v.state != StateSet {
+		return sentinel, nil
+	}
+	return opt.MarshalValueJSON(v.value)
+}
+
+// JSONSentinel supplies the JSON that a Sentinel writes for an unset value.
+// It is implemented by an empty type declared for the purpose:
+//
+//	type unsetMarker struct{}
+//
+//	func (unsetMarker) SentinelJSON() []byte { return []byte(`"__unset__"`) }
+type JSONSentinel interface {
+	SentinelJSON() []byte
+}
+
+// Sentinel is a Val that marshals with MarshalJSONSentinel, writing the JSON
+// supplied by S for an unset value, for struct fields whose consumers need to
+// see a marker. All the other methods of Val are available on it.
+//
+//	type User struct {
+//		Name omit.Sentinel[string, unsetMarker] `json:"name"`
+//	}
+type Sentinel[T any, S JSONSentinel] struct {
+	Val[T]
+}
+
+// MarshalJSON implements json.Marshaler with MarshalJSONSentinel.
+func (s Sentinel[T, S]) MarshalJSON() ([]byte, error) {
+	var sentinel S
+	return s.Val.MarshalJSONSentinel(sentinel.SentinelJSON())
 }
 
 // MarshalJSONIsZero returns true if this value should be omitted by the json
@@ -256,46 +841,90 @@ func (v Val[T]) MarshalJSONIsZero() bool {
 // maps, slices, and ptrs as it was likely a mistake to try to .From(nil)
 // for this type of value anyway.
 func (v Val[T]) IsZero() bool {
-	if v.state == StateUnset {
-		return true
+	return v.state == StateUnset || v.IsNilPayload()
+}
+
+// IsNilPayload returns true if the value is set to a nil map, slice, pointer
+// or interface, which is set but holds nothing and would marshal as null. An
+// unset value has no payload and returns false.
+func (v Val[T]) IsNilPayload() bool {
+	// The kind of T is known without looking at the value, so types that
+	// can never be nil skip reflecting on it, at a small cost to those that
+	// can. Caching the kind per type would cost more than finding it.
+	if v.state != StateSet || !nilableKind(reflect.TypeFor[T]().Kind()) {
+		return false
 	}
 
-	switch reflect.TypeOf(v.value).Kind() {
+	rv := reflect.ValueOf(v.value)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		// Only a nil interface gets here.
+		return true
 	case reflect.Map, reflect.Slice, reflect.Ptr:
-		if reflect.ValueOf(v.value).IsNil() {
-			return true
-		}
+		return rv.IsNil()
 	}
 
 	return false
 }
 
+func nilableKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Interface:
+		return true
+	}
+	return false
+}
+
 func (v Val[T]) IfZero(then func()) {
 	if v.IsZero() {
 		then()
 	}
 }
 
-// MarshalText implements encoding.TextMarshaler.
-func (v Val[T]) MarshalText() ([]byte, error) {
+// MarshalXML implements xml.Marshaler. An unset value emits no element at
+// all, a set value is encoded as T would be.
+//
+// Parent elements from a path tag such as `xml:"a>b"` are written by the
+// encoder before MarshalXML is called, so they still appear when unset.
+func (v Val[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	if v.state != StateSet {
-		return nil, nil
+		return nil
 	}
+	return e.EncodeElement(v.value, start)
+}
 
-	refVal := reflect.ValueOf(v.value)
-	if refVal.Type().Implements(globaldata.EncodingTextMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextMarshaler)
-		return valuer.MarshalText()
+// UnmarshalXML implements xml.Unmarshaler. It is only called when the element
+// is present so it always results in a set value, a missing element leaves
+// the value unset.
+func (v *Val[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var val T
+	if err := d.DecodeElement(&val, &start); err != nil {
+		return err
 	}
+	v.value = val
+	v.state = StateSet
+	return nil
+}
 
-	var text string
-	if err := opt.ConvertAssign(&text, v.value); err != nil {
-		return nil, err
+// MarshalText implements encoding.TextMarshaler. Unset values marshal as
+// empty text, see UnmarshalText for the caveat this brings for set values
+// that are themselves empty. Set values are encoded by opt.MarshalValueText,
+// which falls back to JSON for structs, maps and slices.
+func (v Val[T]) MarshalText() ([]byte, error) {
+	if v.state != StateSet {
+		return nil, nil
 	}
-	return []byte(text), nil
+	return opt.MarshalValueText(v.value)
 }
 
 // UnmarshalText implements encoding.TextUnmarshaler.
+//
+// Empty text is how MarshalText encodes an unset value and so it always
+// unmarshals as unset. This means that a set value whose text form is empty,
+// such as From(""), does not survive a MarshalText/UnmarshalText round trip.
+//
+// This is all that env parsers such as github.com/caarlos0/env need, a field
+// whose variable is absent is never unmarshaled and so stays unset.
 func (v *Val[T]) UnmarshalText(text []byte) error {
 	if len(text) == 0 {
 		var zero T
@@ -304,43 +933,47 @@ func (v *Val[T]) UnmarshalText(text []byte) error {
 		return nil
 	}
 
-	refVal := reflect.ValueOf(&v.value)
-	if refVal.Type().Implements(globaldata.EncodingTextUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextUnmarshaler)
-		if err := valuer.UnmarshalText(text); err != nil {
-			return err
-		}
-		v.state = StateSet
-		return nil
-	}
-
-	if err := opt.ConvertAssign(&v.value, string(text)); err != nil {
+	// Decode into a temporary so that v is untouched if decoding fails.
+	var val T
+	if err := opt.UnmarshalValueText(text, &val); err != nil {
 		return err
 	}
 
+	v.value = val
 	v.state = StateSet
 	return nil
 }
 
-// MarshalBinary tries to encode the value in binary. If it finds
-// type that implements encoding.BinaryMarshaler it will use that,
-// it will fallback to encoding.TextMarshaler if that is implemented,
-// and failing that it will attempt to do some reflect to convert between
-// the types to hit common cases like Go primitives.
+// MarshalBinary implements encoding.BinaryMarshaler. The encoding is a single
+// state byte (0 for unset, 1 for set) followed, when set, by the binary
+// encoding of the value. If its type implements encoding.BinaryMarshaler it
+// will use that, it will fallback to encoding.TextMarshaler if that is
+// implemented, and failing that it will attempt to do some reflect to convert
+// between the types to hit common cases like Go primitives.
 func (v Val[T]) MarshalBinary() ([]byte, error) {
 	if v.state != StateSet {
-		return nil, nil
+		return []byte{0}, nil
 	}
 
-	refVal := reflect.ValueOf(v.value)
-	if refVal.Type().Implements(globaldata.EncodingBinaryMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.BinaryMarshaler)
-		return valuer.MarshalBinary()
+	out := []byte{1}
+
+	valType := reflect.TypeOf(v.value)
+	if globaldata.Implements(valType, globaldata.EncodingBinaryMarshalerIntf) {
+		valuer := any(v.value).(encoding.BinaryMarshaler)
+		b, err := valuer.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return append(out, b...), nil
 	}
 
-	if refVal.Type().Implements(globaldata.EncodingTextMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextMarshaler)
-		return valuer.MarshalText()
+	if globaldata.Implements(valType, globaldata.EncodingTextMarshalerIntf) {
+		valuer := any(v.value).(encoding.TextMarshaler)
+		b, err := valuer.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return append(out, b...), nil
 	}
 
 	var buf []byte
@@ -348,54 +981,140 @@ func (v Val[T]) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
-	return buf, nil
+	return append(out, buf...), nil
 }
 
-// UnmarshalBinary tries to reverse the value MarshalBinary operation.
-// See documentation there for details about supported types.
+// UnmarshalBinary reverses MarshalBinary, see there for the format and
+// supported types. Input that is empty, has an unknown state byte or carries
+// data after the unset state byte is an error, and v is left untouched when
+// an error is returned.
 func (v *Val[T]) UnmarshalBinary(b []byte) error {
 	if len(b) == 0 {
+		return errors.New("invalid binary format for omit.Val: truncated input, missing state byte")
+	}
+
+	switch b[0] {
+	case 0:
+		if len(b) != 1 {
+			return errors.New("invalid binary format for omit.Val: unexpected data after unset state")
+		}
 		var zero T
 		v.value = zero
 		v.state = StateUnset
 		return nil
+	case 1:
+	default:
+		return fmt.Errorf("invalid binary format for omit.Val: unknown state byte %d", b[0])
 	}
 
-	refVal := reflect.ValueOf(&v.value)
-	if refVal.Type().Implements(globaldata.EncodingBinaryUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.BinaryUnmarshaler)
-		if err := valuer.UnmarshalBinary(b); err != nil {
+	// Decode into a temporary so that v is untouched if decoding fails.
+	var val T
+	payload := b[1:]
+	ptrType := reflect.TypeFor[*T]()
+	if globaldata.Implements(ptrType, globaldata.EncodingBinaryUnmarshalerIntf) {
+		valuer := any(&val).(encoding.BinaryUnmarshaler)
+		if err := valuer.UnmarshalBinary(payload); err != nil {
 			return err
 		}
-		v.state = StateSet
-		return nil
+	} else if globaldata.Implements(ptrType, globaldata.EncodingTextUnmarshalerIntf) {
+		valuer := any(&val).(encoding.TextUnmarshaler)
+		if err := valuer.UnmarshalText(payload); err != nil {
+			return err
+		}
+	} else if err := opt.ConvertAssign(&val, payload); err != nil {
+		return err
 	}
 
-	if refVal.Type().Implements(globaldata.EncodingTextUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextUnmarshaler)
-		if err := valuer.UnmarshalText(b); err != nil {
-			return err
+	v.value = val
+	v.state = StateSet
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder, without it gob would silently drop the
+// unexported fields of a Val. It uses the same state byte as MarshalBinary (0
+// for unset, 1 for set) followed, when set, by the gob encoding of the value.
+func (v Val[T]) GobEncode() ([]byte, error) {
+	if v.state != StateSet {
+		return []byte{0}, nil
+	}
+
+	buf := bytes.NewBuffer([]byte{1})
+	if err := gob.NewEncoder(buf).Encode(&v.value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder, reversing GobEncode. Truncated input
+// is an error and v is left untouched when an error is returned.
+func (v *Val[T]) GobDecode(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("invalid gob format for omit.Val: truncated input, missing state byte")
+	}
+
+	switch b[0] {
+	case 0:
+		if len(b) != 1 {
+			return errors.New("invalid gob format for omit.Val: unexpected data after unset state")
 		}
-		v.state = StateSet
+		var zero T
+		v.value = zero
+		v.state = StateUnset
 		return nil
+	case 1:
+	default:
+		return fmt.Errorf("invalid gob format for omit.Val: unknown state byte %d", b[0])
 	}
 
-	if err := opt.ConvertAssign(&v.value, b); err != nil {
+	var val T
+	if err := gob.NewDecoder(bytes.NewReader(b[1:])).Decode(&val); err != nil {
 		return err
 	}
-
+	v.value = val
 	v.state = StateSet
 	return nil
 }
 
 // Scan implements the sql.Scanner interface. If the wrapped type implements
-// sql.Scanner then it will call that.
+// sql.Scanner then it will call that. Scanned time.Time values are converted
+// to opt.ScanLocation if it is set. A driver.Valuer source is first replaced
+// by its Value unless it is already a T, and sql.RawBytes is copied rather
+// than retained.
+//
+// Scanning NULL is handled differently by each of the optional packages,
+// pick the one that matches the column:
+//
+//	package     NULL         value
+//	omit        error        set
+//	null        null         set
+//	omitnull    null         set
+//
+// omitnull never scans as unset, as a column is always present in a row.
+// ScanAll is the exception for omit, it leaves the field of a NULL column
+// unset.
 func (v *Val[T]) Scan(value any) error {
+	value, err := globaldata.ScanSource[T](value)
+	if err != nil {
+		return err
+	}
+
 	if value == nil {
 		return errors.New("cannot store 'null' value in omit value")
 	}
+
+	// Scan into a temporary so that v is untouched if the conversion fails.
+	var val T
+	if err := opt.ConvertAssign(&val, value); err != nil {
+		return err
+	}
+
+	if t, ok := any(&val).(*time.Time); ok && opt.ScanLocation != nil {
+		*t = t.In(opt.ScanLocation)
+	}
+
+	v.value = val
 	v.state = StateSet
-	return opt.ConvertAssign(&v.value, value)
+	return nil
 }
 
 // Value implements the driver.Valuer interface. If the underlying type
@@ -416,9 +1135,50 @@ func (v Val[T]) Value() (driver.Value, error) {
 		return nil, nil
 	}
 
+	// Payloads that are already driver values need no conversion, skip the
+	// interface checks and reflection of ToDriverValue for them.
+	switch val := any(v.value).(type) {
+	case int64, float64, bool, string, []byte, time.Time:
+		return val, nil
+	}
+
 	return opt.ToDriverValue(v.value)
 }
 
+// Key returns a comparable key for v that can be used directly as a map key,
+// such as for grouping or deduplicating on optional values. Unset values share
+// a key that differs from every set value's, including a set zero value, and
+// keys of set values are equal when their values are.
+func Key[T comparable](v Val[T]) struct {
+	Set   bool
+	Value T
+} {
+	if v.state != StateSet {
+		return struct {
+			Set   bool
+			Value T
+		}{}
+	}
+	return struct {
+		Set   bool
+		Value T
+	}{Set: true, Value: v.value}
+}
+
+// Equal reports whether v and other are both unset or both set to deeply
+// equal values (see reflect.DeepEqual). It lets github.com/google/go-cmp
+// compare structs holding Vals without an option for their unexported fields.
+// For comparable types the Equal function avoids reflection.
+func (v Val[T]) Equal(other Val[T]) bool {
+	if v.state != other.state {
+		return false
+	}
+	if v.state != StateSet {
+		return true
+	}
+	return reflect.DeepEqual(v.value, other.value)
+}
+
 // Equal compares two nullable values and returns true if they are equal.
 func Equal[T comparable](a, b Val[T]) bool {
 	if a.state != b.state {
@@ -432,3 +1192,88 @@ func Equal[T comparable](a, b Val[T]) bool {
 
 	return a.value == b.value
 }
+
+// EqualFunc is Equal for any T, using eq to compare set values. It is useful
+// for values that are not comparable such as slices.
+func EqualFunc[T any](a, b Val[T], eq func(T, T) bool) bool {
+	if a.state != b.state {
+		return false
+	}
+
+	if a.state != StateSet {
+		return true
+	}
+
+	return eq(a.value, b.value)
+}
+
+// Compare orders two values, unset values sort before set ones and set values
+// are ordered by cmp.Compare. It returns -1, 0 or +1 like cmp.Compare.
+func Compare[T cmp.Ordered](a, b Val[T]) int {
+	switch {
+	case a.state != StateSet && b.state != StateSet:
+		return 0
+	case a.state != StateSet:
+		return -1
+	case b.state != StateSet:
+		return 1
+	default:
+		return cmp.Compare(a.value, b.value)
+	}
+}
+
+// InRange validates that v is within [min, max], it returns nil if v is unset
+// or holds a value in that range and an error otherwise. Until a later Go
+// version allows methods to constrain T this is a function rather than a
+// method.
+func InRange[T cmp.Ordered](v Val[T], min, max T) error {
+	if v.state != StateSet {
+		return nil
+	}
+	if cmp.Less(v.value, min) || cmp.Less(max, v.value) {
+		return fmt.Errorf("value %v is out of range [%v, %v]", v.value, min, max)
+	}
+	return nil
+}
+
+// EqualConstantTime compares two values holding secrets (tokens, keys) using
+// crypto/subtle so that the comparison does not leak timing information about
+// the payloads. Both states and payloads are always compared, unset values
+// simply compare their empty payloads, so an unset value takes the same path
+// as a set one.
+//
+// As with subtle.ConstantTimeCompare the lengths of the payloads are not
+// protected. T must be a string or a []byte (or have one of those as its
+// underlying type), any other payload never compares equal.
+func (v Val[T]) EqualConstantTime(other Val[T]) bool {
+	a, okA := secretBytes(v.value)
+	b, okB := secretBytes(other.value)
+	if !okA || !okB {
+		return false
+	}
+
+	stateEq := subtle.ConstantTimeEq(int32(v.state), int32(other.state))
+	valueEq := subtle.ConstantTimeCompare(a, b)
+	return stateEq&valueEq == 1
+}
+
+func secretBytes(val any) ([]byte, bool) {
+	switch s := val.(type) {
+	case []byte:
+		return s, true
+	case string:
+		return []byte(s), true
+	}
+
+	refVal := reflect.ValueOf(val)
+	switch refVal.Kind() {
+	case reflect.String:
+		return []byte(refVal.String()), true
+	case reflect.Slice:
+		if refVal.Type().Elem().Kind() == reflect.Uint8 {
+			return refVal.Bytes(), true
+		}
+	}
+
+	return nil, false
+}
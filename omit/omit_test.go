@@ -2,8 +2,18 @@ package omit
 
 import (
 	"bytes"
+	"context"
 	"database/sql/driver"
+	"encoding/gob"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
 	"net"
+	"reflect"
+	"slices"
 	"testing"
 	"time"
 
@@ -44,6 +54,117 @@ func TestConstruction(t *testing.T) {
 	}
 }
 
+func TestFromPtrRequired(t *testing.T) {
+	t.Parallel()
+
+	hello := "hello"
+	val, err := FromPtrRequired(&hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val.MustGet() != "hello" {
+		t.Error("wrong value")
+	}
+
+	if val, err = FromPtrRequired[string](nil); err == nil {
+		t.Error("expected an error for a nil pointer")
+	}
+	checkState(t, val, StateUnset)
+}
+
+func TestFromValid(t *testing.T) {
+	t.Parallel()
+
+	errNegative := errors.New("must not be negative")
+	positive := func(i int) error {
+		if i < 0 {
+			return errNegative
+		}
+		return nil
+	}
+
+	val, err := FromValid(5, positive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val.MustGet() != 5 {
+		t.Error("wrong value")
+	}
+
+	if val, err = FromValid(-1, positive); !errors.Is(err, errNegative) {
+		t.Error("expected the validation error, got:", err)
+	}
+	checkState(t, val, StateUnset)
+
+	if MustFromValid(5, positive).MustGet() != 5 {
+		t.Error("wrong value")
+	}
+
+	defer func() {
+		if r := recover(); r != errNegative {
+			t.Error("expected a panic with the validation error, got:", r)
+		}
+	}()
+	_ = MustFromValid(-1, positive)
+}
+
+func TestFromFloat(t *testing.T) {
+	t.Parallel()
+
+	checkState(t, FromFloat(math.NaN()), StateUnset)
+	checkState(t, FromFloat(math.Inf(1)), StateUnset)
+	checkState(t, FromFloat(math.Inf(-1)), StateUnset)
+	if v := FromFloat(1.5); v.GetOrZero() != 1.5 {
+		t.Error("wrong value:", v)
+	}
+	checkState(t, FromFloat(0), StateSet)
+}
+
+func TestFromDeadline(t *testing.T) {
+	t.Parallel()
+
+	checkState(t, FromDeadline(context.Background()), StateUnset)
+
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	if got := FromDeadline(ctx).MustGet(); !got.Equal(deadline) {
+		t.Error("wrong deadline:", got)
+	}
+}
+
+func TestFromRetry(t *testing.T) {
+	t.Parallel()
+
+	failUntil := func(n int) (func() (int, error), *int) {
+		calls := 0
+		return func() (int, error) {
+			calls++
+			if calls < n {
+				return 0, errors.New("not yet")
+			}
+			return calls, nil
+		}, &calls
+	}
+
+	fn, calls := failUntil(1)
+	if val := FromRetry(3, fn); val.MustGet() != 1 || *calls != 1 {
+		t.Error("wrong value or calls:", val, *calls)
+	}
+
+	fn, calls = failUntil(3)
+	if val := FromRetry(3, fn); val.MustGet() != 3 || *calls != 3 {
+		t.Error("wrong value or calls:", val, *calls)
+	}
+
+	fn, calls = failUntil(4)
+	checkState(t, FromRetry(3, fn), StateUnset)
+	if *calls != 3 {
+		t.Error("wrong number of calls:", *calls)
+	}
+}
+
 func TestGet(t *testing.T) {
 	t.Parallel()
 
@@ -78,6 +199,54 @@ func TestGet(t *testing.T) {
 	_ = val.MustGet()
 }
 
+func TestGetOrFunc(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	fallback := func() string {
+		called = true
+		return "hi"
+	}
+
+	if From("hello").GetOrFunc(fallback) != "hello" {
+		t.Error("wrong value")
+	}
+	if called {
+		t.Error("fn should not be called when the value is set")
+	}
+	if (Val[string]{}).GetOrFunc(fallback) != "hi" {
+		t.Error("wrong value")
+	}
+	if !called {
+		t.Error("fn should be called when the value is unset")
+	}
+}
+
+func TestGetOrZeroLog(t *testing.T) {
+	t.Parallel()
+
+	misses := 0
+	onMiss := func() { misses++ }
+
+	if From("hello").GetOrZeroLog(onMiss) != "hello" {
+		t.Error("wrong value")
+	}
+	if misses != 0 {
+		t.Error("onMiss should not fire for a set value")
+	}
+
+	if (Val[string]{}).GetOrZeroLog(onMiss) != "" {
+		t.Error("wrong value")
+	}
+	if misses != 1 {
+		t.Error("onMiss should fire once for an unset value, fired:", misses)
+	}
+
+	if (Val[string]{}).GetOrZeroLog(nil) != "" {
+		t.Error("wrong value")
+	}
+}
+
 func TestOr(t *testing.T) {
 	t.Parallel()
 
@@ -100,6 +269,60 @@ func TestOr(t *testing.T) {
 	}
 }
 
+func TestGetOrErr(t *testing.T) {
+	t.Parallel()
+
+	errMissing := errors.New("missing")
+	if v, err := From(5).GetOrErr(errMissing); err != nil || v != 5 {
+		t.Error("wrong result:", v, err)
+	}
+	if v, err := (Val[int]{}).GetOrErr(errMissing); !errors.Is(err, errMissing) || v != 0 {
+		t.Error("expected the error, got:", v, err)
+	}
+
+	if v, err := From(5).GetOrErrf("missing %s", "id"); err != nil || v != 5 {
+		t.Error("wrong result:", v, err)
+	}
+	if _, err := (Val[int]{}).GetOrErrf("missing %s: %w", "id", errMissing); err == nil ||
+		err.Error() != "missing id: missing" || !errors.Is(err, errMissing) {
+		t.Error("wrong error:", err)
+	}
+}
+
+func TestOrElse(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	fallback := func() Val[int] {
+		called = true
+		return From(6)
+	}
+
+	if From(5).OrElse(fallback).MustGet() != 5 {
+		t.Error("it should have returned 5")
+	}
+	if called {
+		t.Error("fallback should not be called when set")
+	}
+	if (Val[int]{}).OrElse(fallback).MustGet() != 6 {
+		t.Error("it should have returned 6")
+	}
+	if !called {
+		t.Error("fallback should be called when unset")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	t.Parallel()
+
+	var unset Val[int]
+	if val := Coalesce(unset, From(5), From(6)); val.MustGet() != 5 {
+		t.Error("it should have returned 5")
+	}
+	checkState(t, Coalesce(unset, unset), StateUnset)
+	checkState(t, Coalesce[int](), StateUnset)
+}
+
 func TestMap(t *testing.T) {
 	t.Parallel()
 
@@ -119,93 +342,798 @@ func TestMap(t *testing.T) {
 	}
 }
 
-func TestChanges(t *testing.T) {
+func TestZip(t *testing.T) {
+	t.Parallel()
+
+	pair := Zip(From("host"), From(80))
+	if p := pair.MustGet(); p.A != "host" || p.B != 80 {
+		t.Error("wrong pair:", p)
+	}
+	checkState(t, Zip(From("host"), Val[int]{}), StateUnset)
+	checkState(t, Zip(Val[string]{}, From(80)), StateUnset)
+
+	join := func(host string, port int) string { return fmt.Sprintf("%s:%d", host, port) }
+	if addr := ZipWith(From("host"), From(80), join); addr.MustGet() != "host:80" {
+		t.Error("wrong address:", addr)
+	}
+	unset := ZipWith(Val[string]{}, From(80), func(string, int) string {
+		t.Error("fn should not be called")
+		return ""
+	})
+	checkState(t, unset, StateUnset)
+}
+
+func TestFlatMap(t *testing.T) {
+	t.Parallel()
+
+	names := map[int]string{1: "one"}
+	lookup := func(i int) Val[string] {
+		name, ok := names[i]
+		if !ok {
+			return Val[string]{}
+		}
+		return From(name)
+	}
+
+	if FlatMap(From(1), lookup).MustGet() != "one" {
+		t.Error("wrong value")
+	}
+	checkState(t, FlatMap(From(2), lookup), StateUnset)
+	called := false
+	checkState(t, FlatMap(Val[int]{}, func(int) Val[string] {
+		called = true
+		return From("")
+	}), StateUnset)
+	if called {
+		t.Error("fn should not be called when v is not set")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	positive := func(i int) bool { return i > 0 }
+
+	if From(5).Filter(positive).MustGet() != 5 {
+		t.Error("it should have kept the value")
+	}
+	checkState(t, From(-5).Filter(positive), StateUnset)
+
+	called := false
+	checkState(t, Val[int]{}.Filter(func(int) bool {
+		called = true
+		return true
+	}), StateUnset)
+	if called {
+		t.Error("keep should not be called on an unset value")
+	}
+}
+
+type wrapError struct {
+	Val[error]
+	msg string
+}
+
+func (e wrapError) Error() string { return e.msg }
+
+func TestUnwrap(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("cause")
+
+	var err error = wrapError{Val: From[error](cause), msg: "wrapped"}
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is should find the set cause")
+	}
+
+	err = wrapError{msg: "no cause"}
+	if errors.Is(err, cause) {
+		t.Error("errors.Is should stop at an unset cause")
+	}
+	if errors.Unwrap(err) != nil {
+		t.Error("an unset cause should unwrap to nil")
+	}
+
+	if From(5).Unwrap() != nil {
+		t.Error("a non-error payload should unwrap to nil")
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	if s := From(5).String(); s != "5" {
+		t.Error("wrong string:", s)
+	}
+	if s := From(net.IPv4(1, 1, 1, 1)).String(); s != "1.1.1.1" {
+		t.Error("a Stringer payload should be used:", s)
+	}
+	if s := (Val[int]{}).String(); s != "<unset>" {
+		t.Error("wrong string:", s)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	type point struct{ X, Y int }
+
+	for _, tc := range []struct {
+		format string
+		val    any
+		want   string
+	}{
+		{"%v", From(5), "5"},
+		{"%d", From(5), "5"},
+		{"%05.1f", From(1.25), "001.2"},
+		{"%q", From("hi"), `"hi"`},
+		{"%x", From("hi"), "6869"},
+		{"%+v", From(point{1, 2}), "{X:1 Y:2}"},
+		{"%v", From(net.IPv4(1, 1, 1, 1)), "1.1.1.1"},
+		{"%v", Val[int]{}, "<unset>"},
+		{"%q", Val[string]{}, "<unset>"},
+		{"%+v", struct{ A Val[int] }{From(1)}, "{A:1}"},
+	} {
+		if got := fmt.Sprintf(tc.format, tc.val); got != tc.want {
+			t.Errorf("%s: want %s, got %s", tc.format, tc.want, got)
+		}
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	t.Parallel()
+
+	val := From(5).LogValue()
+	if val.Kind() != slog.KindInt64 || val.Int64() != 5 {
+		t.Error("wrong log value:", val)
+	}
+
+	val = From("hello").LogValue()
+	if val.Kind() != slog.KindString || val.String() != "hello" {
+		t.Error("wrong log value:", val)
+	}
+
+	val = Val[int]{}.LogValue()
+	if val.Kind() != slog.KindString || val.String() != "<unset>" {
+		t.Error("wrong log value:", val)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("msg", "age", From(5), "name", Val[string]{})
+	if got := buf.String(); got != "level=INFO msg=msg age=5 name=<unset>\n" {
+		t.Errorf("wrong log output: %q", got)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	t.Parallel()
+
+	set := From("hello")
+	exported := set.Export()
+	if !exported.Set || exported.Value != "hello" {
+		t.Error("wrong export:", exported)
+	}
+	if !Equal(Import(exported), set) {
+		t.Error("round trip failed")
+	}
+
+	var unset Val[string]
+	exported = unset.Export()
+	if exported.Set || exported.Value != "" {
+		t.Error("wrong export:", exported)
+	}
+	checkState(t, Import(exported), StateUnset)
+
+	b, err := opt.JSONMarshal(set.Export())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"Value":"hello","Set":true}` {
+		t.Error("wrong json:", string(b))
+	}
+}
+
+func TestMergeMap(t *testing.T) {
+	t.Parallel()
+
+	base := From(map[string]int{"a": 1, "b": 2})
+	patch := From(map[string]int{"b": 3, "c": 4})
+
+	if got := MergeMap(base, Val[map[string]int]{}).MustGet(); !maps.Equal(got, map[string]int{"a": 1, "b": 2}) {
+		t.Error("wrong base-only merge:", got)
+	}
+	if got := MergeMap(Val[map[string]int]{}, patch).MustGet(); !maps.Equal(got, map[string]int{"b": 3, "c": 4}) {
+		t.Error("wrong patch-only merge:", got)
+	}
+	if got := MergeMap(base, patch).MustGet(); !maps.Equal(got, map[string]int{"a": 1, "b": 3, "c": 4}) {
+		t.Error("wrong overlapping merge:", got)
+	}
+	if base.MustGet()["b"] != 2 {
+		t.Error("base should not be modified")
+	}
+
+	checkState(t, MergeMap(Val[map[string]int]{}, Val[map[string]int]{}), StateUnset)
+}
+
+func TestAssert(t *testing.T) {
+	t.Parallel()
+
+	if v := Assert[string](any("hello")); v.GetOrZero() != "hello" {
+		t.Error("wrong value:", v)
+	}
+	checkState(t, Assert[int](any("hello")), StateUnset)
+	checkState(t, Assert[any](nil), StateUnset)
+	checkState(t, Assert[error](nil), StateUnset)
+
+	var err error = &wrapError{}
+	checkState(t, Assert[error](err), StateSet)
+}
+
+func TestAsAnyCast(t *testing.T) {
+	t.Parallel()
+
+	erased := From(5).AsAny()
+	val, ok := Cast[int](erased)
+	if !ok || val.MustGet() != 5 {
+		t.Error("wrong value:", val, ok)
+	}
+
+	val, ok = Cast[int](From[any]("five"))
+	if ok {
+		t.Error("a mismatched type should not be ok")
+	}
+	checkState(t, val, StateUnset)
+
+	val, ok = Cast[int](Val[int]{}.AsAny())
+	if ok {
+		t.Error("an unset value should not be ok")
+	}
+	checkState(t, val, StateUnset)
+}
+
+func TestMapLookup(t *testing.T) {
+	t.Parallel()
+
+	labels := map[int]string{1: "one", 2: "two"}
+
+	if MapLookup(From(2), labels).MustGet() != "two" {
+		t.Error("wrong value")
+	}
+	checkState(t, MapLookup(From(3), labels), StateUnset)
+	checkState(t, MapLookup(Val[int]{}, labels), StateUnset)
+	checkState(t, MapLookup[int, string](From(1), nil), StateUnset)
+}
+
+func TestAsMapEntry(t *testing.T) {
+	t.Parallel()
+
+	m := From(5).AsMapEntry("age")
+	if len(m) != 1 || m["age"] != 5 {
+		t.Error("wrong map:", m)
+	}
+
+	m = Val[int]{}.AsMapEntry("age")
+	if m == nil || len(m) != 0 {
+		t.Error("expected an empty map:", m)
+	}
+}
+
+func TestMutateIfSet(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Retries int
+	}
+
+	val := From(config{Retries: 1})
+	val.MutateIfSet(func(c *config) { c.Retries++ })
+	if val.MustGet().Retries != 2 {
+		t.Error("value should have been mutated")
+	}
+
+	var unset Val[config]
+	unset.MutateIfSet(func(c *config) { t.Error("should not be called") })
+	checkState(t, unset, StateUnset)
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	host, port := From("localhost"), Val[string]{}
+	var addr string
+	err := Recover(func() {
+		addr = host.MustGet() + ":" + port.MustGet()
+	})
+	if !errors.Is(err, ErrNoValue) {
+		t.Error("expected ErrNoValue, got:", err)
+	}
+	if addr != "" {
+		t.Error("fn should have stopped at the panic:", addr)
+	}
+
+	if err := Recover(func() { addr = host.MustGet() }); err != nil || addr != "localhost" {
+		t.Error("unexpected result:", addr, err)
+	}
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Error("expected the foreign panic to propagate, got:", r)
+		}
+	}()
+	_ = Recover(func() { panic("boom") })
+	t.Error("should not be reached")
+}
+
+func TestAsOption(t *testing.T) {
+	t.Parallel()
+
+	var got []int
+	apply := func(i int) { got = append(got, i) }
+
+	opts := []func(){
+		AsOption(From(1), apply),
+		AsOption(Val[int]{}, apply),
+		AsOption(From(3), apply),
+	}
+	if len(got) != 0 {
+		t.Error("apply should not run until the option does")
+	}
+	for _, o := range opts {
+		o()
+	}
+	if !slices.Equal(got, []int{1, 3}) {
+		t.Error("apply should only run for set values:", got)
+	}
+}
+
+func TestIsSetAndContains(t *testing.T) {
+	t.Parallel()
+
+	positive := func(i int) bool { return i > 0 }
+	if !From(5).IsSetAnd(positive) {
+		t.Error("5 is positive")
+	}
+	if From(-5).IsSetAnd(positive) {
+		t.Error("-5 is not positive")
+	}
+	if (Val[int]{}).IsSetAnd(func(int) bool { t.Error("pred should not be called"); return true }) {
+		t.Error("unset should be false")
+	}
+	if !From([]int{1}).IsSetAnd(func(s []int) bool { return len(s) == 1 }) {
+		t.Error("should work for non-comparable types")
+	}
+
+	if !Contains(From("a"), "a") || Contains(From("a"), "b") {
+		t.Error("wrong result for set values")
+	}
+	if Contains(Val[string]{}, "") {
+		t.Error("unset should not contain the zero value")
+	}
+}
+
+func TestPtr(t *testing.T) {
+	t.Parallel()
+
+	val := From(5)
+	ptr := val.Ptr()
+	if ptr == nil || *ptr != 5 {
+		t.Fatal("wrong pointer")
+	}
+	*ptr = 6
+	if val.MustGet() != 5 {
+		t.Error("mutating through the pointer should not change the value")
+	}
+
+	if (Val[int]{}).Ptr() != nil {
+		t.Error("unset should be nil")
+	}
+}
+
+func TestGetPtr(t *testing.T) {
+	t.Parallel()
+
+	val := From(5)
+	ptr, ok := val.GetPtr()
+	if !ok || ptr == nil || *ptr != 5 {
+		t.Fatal("wrong pointer")
+	}
+	*ptr = 6
+	if val.MustGet() != 6 {
+		t.Error("mutating through the pointer should change the value")
+	}
+
+	var unset Val[int]
+	if ptr, ok := unset.GetPtr(); ok || ptr != nil {
+		t.Error("unset should be nil")
+	}
+}
+
+func TestSetIfUnset(t *testing.T) {
+	t.Parallel()
+
+	var val Val[int]
+	if !val.SetIfUnset(5) {
+		t.Error("it should have set the unset value")
+	}
+	if val.MustGet() != 5 {
+		t.Error("wrong value")
+	}
+
+	if val.SetIfUnset(6) {
+		t.Error("it should not override a set value")
+	}
+	if val.MustGet() != 5 {
+		t.Error("wrong value")
+	}
+}
+
+func TestSwapReplace(t *testing.T) {
+	t.Parallel()
+
+	var val Val[int]
+	if prev, wasSet := val.Swap(5); wasSet || prev != 0 {
+		t.Error("unset should have no previous value:", prev, wasSet)
+	}
+	if prev, wasSet := val.Swap(6); !wasSet || prev != 5 {
+		t.Error("wrong previous value:", prev, wasSet)
+	}
+	if val.MustGet() != 6 {
+		t.Error("wrong value:", val.MustGet())
+	}
+
+	if !val.Replace(7) || val.MustGet() != 7 {
+		t.Error("set value should be replaced")
+	}
+	val.Unset()
+	if val.Replace(8) {
+		t.Error("unset value should not be replaced")
+	}
+	checkState(t, val, StateUnset)
+}
+
+func TestChanges(t *testing.T) {
+	t.Parallel()
+
+	val := From("hello")
+	checkState(t, val, StateSet)
+	val.Unset()
+	checkState(t, val, StateUnset)
+
+	val = Val[string]{}
+	checkState(t, val, StateUnset)
+	val.Set("hello")
+	checkState(t, val, StateSet)
+
+	val = Val[string]{}
+	checkState(t, val, StateUnset)
+}
+
+func TestWithValueCleared(t *testing.T) {
+	t.Parallel()
+
+	base := Val[int]{}
+	set := base.WithValue(30)
+	checkState(t, base, StateUnset)
+	checkState(t, set, StateSet)
+	if set.MustGet() != 30 {
+		t.Error("wrong value:", set.MustGet())
+	}
+
+	cleared := set.Cleared()
+	checkState(t, cleared, StateUnset)
+	checkState(t, set, StateSet)
+	if cleared.GetOrZero() != 0 {
+		t.Error("cleared should not hold a value")
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	val := From("hello")
+	checkJSON(t, val, `"hello"`)
+	val.Unset()
+	checkJSON(t, val, `null`)
+}
+
+func TestIsNilPayload(t *testing.T) {
+	t.Parallel()
+
+	if !From[[]int](nil).IsNilPayload() {
+		t.Error("nil slice should be a nil payload")
+	}
+	if !From[map[string]int](nil).IsNilPayload() {
+		t.Error("nil map should be a nil payload")
+	}
+	if !From[*int](nil).IsNilPayload() {
+		t.Error("nil pointer should be a nil payload")
+	}
+	if !From[any](nil).IsNilPayload() {
+		t.Error("nil interface should be a nil payload")
+	}
+
+	five := 5
+	if From([]int{}).IsNilPayload() || From(map[string]int{}).IsNilPayload() || From(&five).IsNilPayload() {
+		t.Error("non-nil payloads should not be nil")
+	}
+	if From(0).IsNilPayload() {
+		t.Error("a zero int is not nil")
+	}
+	if (Val[[]int]{}).IsNilPayload() {
+		t.Error("unset has no payload")
+	}
+}
+
+func TestMarshalJSONIsZero(t *testing.T) {
+	type testStruct struct {
+		ID Val[int] `json:"id,omitzero"`
+	}
+
+	valSlice := Val[[]int]{}
+	valSlice.Set(nil)
+	if !valSlice.MarshalJSONIsZero() {
+		t.Error("should be zero")
+	}
+
+	valMap := Val[map[string]int]{}
+	valMap.Set(nil)
+	if !valMap.MarshalJSONIsZero() {
+		t.Error("should be zero")
+	}
+
+	valStruct := Val[*testStruct]{}
+	valStruct.Set(nil)
+	if !valStruct.MarshalJSONIsZero() {
+		t.Error("should be zero")
+	}
+
+	b, err := opt.JSONMarshal(testStruct{})
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b) != `{}` {
+		t.Errorf("expected empty json object, got: %s", b)
+	}
+
+	b, err = opt.JSONMarshal(testStruct{ID: From(0)})
+	if err != nil {
+		t.Error(err)
+	}
+	if string(b) != `{"id":0}` {
+		t.Errorf("expected non-empty json object, got: %s", b)
+	}
+}
+
+type testColor int
+
+const (
+	testColorRed testColor = iota
+	testColorBlue
+)
+
+func init() {
+	opt.RegisterEnum(map[testColor]string{
+		testColorRed:  "red",
+		testColorBlue: "blue",
+	})
+}
+
+func TestEnumJSON(t *testing.T) {
+	t.Parallel()
+
+	type palette struct {
+		Primary   Val[testColor] `json:"primary"`
+		Secondary Val[testColor] `json:"secondary,omitzero"`
+	}
+
+	b, err := opt.JSONMarshal(palette{Primary: From(testColorBlue)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"primary":"blue"}` {
+		t.Error("wrong json:", string(b))
+	}
+
+	var p palette
+	if err := opt.JSONUnmarshal([]byte(`{"primary":"red"}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Primary.MustGet() != testColorRed {
+		t.Error("wrong value:", p.Primary.MustGet())
+	}
+	checkState(t, p.Secondary, StateUnset)
+
+	if err := opt.JSONUnmarshal([]byte(`{"primary":"green"}`), &p); err == nil {
+		t.Error("expected an error for an unknown name")
+	}
+}
+
+func TestUnmarshalJSONPadding(t *testing.T) {
+	t.Parallel()
+
+	val := From("hello")
+	if err := val.UnmarshalJSON(nil); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateUnset)
+	val = From("hello")
+	if err := val.UnmarshalJSON([]byte{}); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateUnset)
+	val = From("hello")
+	if err := val.UnmarshalJSON([]byte(" \n\t")); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateUnset)
+
+	if err := val.UnmarshalJSON([]byte(" null ")); err == nil {
+		t.Error("a padded null should be recognized and refused")
+	}
+	if err := val.UnmarshalJSONWith([]byte(" null\n"), DecodeOptions{NullAsUnset: true}); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateUnset)
+
+	if err := val.UnmarshalJSON([]byte(` "hello" `)); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateSet)
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	hello := Val[string]{}
+	checkState(t, hello, StateUnset)
+
+	if err := opt.JSONUnmarshal([]byte("null"), &hello); err == nil {
+		t.Error("cannot accept a null")
+	}
+
+	if err := opt.JSONUnmarshal([]byte(`"hello"`), &hello); err != nil {
+		t.Error(err)
+	}
+	checkState(t, hello, StateSet)
+
+	if hello.MustGet() != "hello" {
+		t.Error("expected hello")
+	}
+
+	hello.UnmarshalJSON(nil)
+	checkState(t, hello, StateUnset)
+}
+
+type xmlAddress struct {
+	City Val[string] `xml:"city"`
+	Zip  Val[int]    `xml:"zip"`
+}
+
+type xmlPerson struct {
+	XMLName xml.Name        `xml:"person"`
+	Name    Val[string]     `xml:"name"`
+	Age     Val[int]        `xml:"age"`
+	Address Val[xmlAddress] `xml:"address"`
+}
+
+func TestXML(t *testing.T) {
 	t.Parallel()
 
-	val := From("hello")
-	checkState(t, val, StateSet)
-	val.Unset()
-	checkState(t, val, StateUnset)
+	in := xmlPerson{
+		Name:    From("hello"),
+		Address: From(xmlAddress{City: From("Paris")}),
+	}
+	b, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<person><name>hello</name><address><city>Paris</city></address></person>`
+	if string(b) != want {
+		t.Errorf("wrong xml:\nwant: %s\ngot:  %s", want, b)
+	}
 
-	val = Val[string]{}
-	checkState(t, val, StateUnset)
-	val.Set("hello")
-	checkState(t, val, StateSet)
+	var out xmlPerson
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name.MustGet() != "hello" {
+		t.Error("wrong name:", out.Name)
+	}
+	checkState(t, out.Age, StateUnset)
+	address := out.Address.MustGet()
+	if address.City.MustGet() != "Paris" {
+		t.Error("wrong city:", address.City)
+	}
+	checkState(t, address.Zip, StateUnset)
 
-	val = Val[string]{}
-	checkState(t, val, StateUnset)
+	if err := xml.Unmarshal([]byte(`<person><age>x</age></person>`), &out); err == nil {
+		t.Error("expected an error for a bad age")
+	}
 }
 
-func TestMarshalJSON(t *testing.T) {
+func TestMarshalJSONSentinel(t *testing.T) {
 	t.Parallel()
 
-	val := From("hello")
-	checkJSON(t, val, `"hello"`)
-	val.Unset()
-	checkJSON(t, val, `null`)
+	sentinel := []byte(`"__unset__"`)
+	if b, err := (Val[int]{}).MarshalJSONSentinel(sentinel); err != nil || string(b) != `"__unset__"` {
+		t.Error("wrong sentinel:", string(b), err)
+	}
+	if b, err := From(5).MarshalJSONSentinel(sentinel); err != nil || string(b) != `5` {
+		t.Error("wrong value:", string(b), err)
+	}
 }
 
-func TestMarshalJSONIsZero(t *testing.T) {
-	type testStruct struct {
-		ID Val[int] `json:"id,omitzero"`
-	}
+type unsetMarker struct{}
 
-	valSlice := Val[[]int]{}
-	valSlice.Set(nil)
-	if !valSlice.MarshalJSONIsZero() {
-		t.Error("should be zero")
-	}
+func (unsetMarker) SentinelJSON() []byte { return []byte(`"__unset__"`) }
 
-	valMap := Val[map[string]int]{}
-	valMap.Set(nil)
-	if !valMap.MarshalJSONIsZero() {
-		t.Error("should be zero")
-	}
+func TestSentinel(t *testing.T) {
+	t.Parallel()
 
-	valStruct := Val[*testStruct]{}
-	valStruct.Set(nil)
-	if !valStruct.MarshalJSONIsZero() {
-		t.Error("should be zero")
+	type doc struct {
+		A Val[int]                   `json:"a"`
+		B Sentinel[int, unsetMarker] `json:"b"`
+		C Sentinel[int, unsetMarker] `json:"c,omitzero"`
 	}
 
-	b, err := opt.JSONMarshal(testStruct{})
+	b, err := opt.JSONMarshal(doc{})
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if string(b) != `{}` {
-		t.Errorf("expected empty json object, got: %s", b)
+	if string(b) != `{"a":null,"b":"__unset__"}` {
+		t.Error("wrong output:", string(b))
 	}
 
-	b, err = opt.JSONMarshal(testStruct{ID: From(0)})
+	b, err = opt.JSONMarshal(doc{B: Sentinel[int, unsetMarker]{From(1)}})
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if string(b) != `{"id":0}` {
-		t.Errorf("expected non-empty json object, got: %s", b)
+	if string(b) != `{"a":null,"b":1}` {
+		t.Error("wrong output:", string(b))
 	}
 }
 
-func TestUnmarshalJSON(t *testing.T) {
+func TestMarshalJSONStrict(t *testing.T) {
 	t.Parallel()
 
-	hello := Val[string]{}
-	checkState(t, hello, StateUnset)
-
-	if err := opt.JSONUnmarshal([]byte("null"), &hello); err == nil {
-		t.Error("cannot accept a null")
+	if _, err := (Val[int]{}).MarshalJSONStrict(); !errors.Is(err, ErrUnsetJSON) {
+		t.Error("expected ErrUnsetJSON, got:", err)
+	}
+	if b, err := From(1).MarshalJSONStrict(); err != nil || string(b) != "1" {
+		t.Error("wrong output:", string(b), err)
 	}
 
-	if err := opt.JSONUnmarshal([]byte(`"hello"`), &hello); err != nil {
-		t.Error(err)
+	type doc struct {
+		A Strict[int] `json:"a"`
+		B Strict[int] `json:"b,omitzero"`
+		C Val[int]    `json:"c"`
 	}
-	checkState(t, hello, StateSet)
 
-	if hello.MustGet() != "hello" {
-		t.Error("expected hello")
+	if _, err := opt.JSONMarshal(doc{}); !errors.Is(err, ErrUnsetJSON) {
+		t.Error("expected ErrUnsetJSON, got:", err)
+	}
+	b, err := opt.JSONMarshal(doc{A: Strict[int]{From(1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"a":1,"c":null}` {
+		t.Error("omitted fields should not error:", string(b))
 	}
 
-	hello.UnmarshalJSON(nil)
-	checkState(t, hello, StateUnset)
+	var d doc
+	if err := opt.JSONUnmarshal([]byte(`{"a":2}`), &d); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, d.A.Val, StateSet)
+	checkState(t, d.B.Val, StateUnset)
 }
 
 func TestMarshalText(t *testing.T) {
@@ -237,6 +1165,42 @@ func TestMarshalText(t *testing.T) {
 	}
 }
 
+func TestMarshalTextDynamicType(t *testing.T) {
+	t.Parallel()
+
+	// The interface check must follow the dynamic type of the payload, not
+	// whatever was cached for an earlier value of the same Val type.
+	for i := 0; i < 2; i++ {
+		if b, err := From[any](net.IPv4(1, 1, 1, 1)).MarshalText(); err != nil {
+			t.Error(err)
+		} else if string(b) != "1.1.1.1" {
+			t.Error("wrong value:", string(b))
+		}
+		if b, err := From[any]("hello").MarshalText(); err != nil {
+			t.Error(err)
+		} else if string(b) != "hello" {
+			t.Error("wrong value:", string(b))
+		}
+	}
+}
+
+func BenchmarkMarshalText(b *testing.B) {
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		val := From("hello")
+		for b.Loop() {
+			_, _ = val.MarshalText()
+		}
+	})
+	b.Run("marshaler", func(b *testing.B) {
+		b.ReportAllocs()
+		val := From(net.IPv4(1, 1, 1, 1))
+		for b.Loop() {
+			_, _ = val.MarshalText()
+		}
+	})
+}
+
 func TestUnmarshalText(t *testing.T) {
 	t.Parallel()
 
@@ -277,8 +1241,8 @@ func TestMarshalBinary(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	if string(b) != "hello" {
-		t.Error("expected hello in ascii bytes")
+	if string(b) != "\x01hello" {
+		t.Error("expected a set byte then hello in ascii bytes")
 	}
 
 	hello.Unset()
@@ -286,14 +1250,14 @@ func TestMarshalBinary(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	if len(b) != 0 {
-		t.Error("expected empty byte slice")
+	if !bytes.Equal(b, []byte{0}) {
+		t.Error("expected only an unset byte")
 	}
 
 	marshaller := From(net.IPv4(1, 1, 1, 1))
 	if b, err := marshaller.MarshalBinary(); err != nil {
 		t.Error(err)
-	} else if !bytes.Equal(b, []byte("1.1.1.1")) {
+	} else if !bytes.Equal(b, []byte("\x011.1.1.1")) {
 		t.Error("wrong value")
 	}
 }
@@ -301,13 +1265,13 @@ func TestMarshalBinary(t *testing.T) {
 func TestUnmarshalBinary(t *testing.T) {
 	t.Parallel()
 
-	var val Val[string]
-	if err := val.UnmarshalBinary([]byte{}); err != nil {
+	val := From("hi")
+	if err := val.UnmarshalBinary([]byte{0}); err != nil {
 		t.Error(err)
 	}
 	checkState(t, val, StateUnset)
 
-	if err := val.UnmarshalBinary([]byte("hello")); err != nil {
+	if err := val.UnmarshalBinary([]byte("\x01hello")); err != nil {
 		t.Error(err)
 	}
 	checkState(t, val, StateSet)
@@ -315,19 +1279,89 @@ func TestUnmarshalBinary(t *testing.T) {
 		t.Error("wrong value")
 	}
 
-	var unmarshaller Val[net.IP]
-	if err := unmarshaller.UnmarshalBinary([]byte{}); err != nil {
+	if err := val.UnmarshalBinary([]byte{1}); err != nil {
 		t.Error(err)
 	}
-	checkState(t, unmarshaller, StateUnset)
+	if val.MustGet() != "" {
+		t.Error("a set byte alone should be an empty value")
+	}
 
-	if err := unmarshaller.UnmarshalBinary([]byte("1.1.1.1")); err != nil {
+	var unmarshaller Val[net.IP]
+	if err := unmarshaller.UnmarshalBinary([]byte("\x011.1.1.1")); err != nil {
 		t.Error(err)
 	}
 	checkState(t, unmarshaller, StateSet)
 	if !unmarshaller.MustGet().Equal(net.IPv4(1, 1, 1, 1)) {
 		t.Error("wrong value")
 	}
+
+	val = From("hello")
+	for _, bad := range [][]byte{nil, {}, {2}, {0, 1}} {
+		if err := val.UnmarshalBinary(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+		if val.MustGet() != "hello" {
+			t.Errorf("%q: the value should be untouched", bad)
+		}
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []Val[string]{From("hello"), From(""), {}} {
+		b, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out Val[string]
+		if err := out.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(in, out) {
+			t.Errorf("round trip changed %v into %v", in, out)
+		}
+	}
+}
+
+type gobRecord struct {
+	Name    Val[string]
+	Count   Val[int]
+	Zero    Val[int]
+	Tags    Val[[]string]
+	Created Val[time.Time]
+	Missing Val[float64]
+}
+
+func TestGob(t *testing.T) {
+	t.Parallel()
+
+	in := gobRecord{
+		Name:    From("hello"),
+		Count:   From(5),
+		Zero:    From(0),
+		Tags:    From([]string{"a", "b"}),
+		Created: From(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out gobRecord
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip mismatch:\nwant: %#v\ngot:  %#v", in, out)
+	}
+	var val Val[int]
+	if err := val.GobDecode([]byte{2}); err == nil {
+		t.Error("expected an error for an unknown state byte")
+	}
+	if err := val.GobDecode(nil); err == nil {
+		t.Error("expected an error for truncated input")
+	}
 }
 
 func TestScan(t *testing.T) {
@@ -345,6 +1379,62 @@ func TestScan(t *testing.T) {
 	if val.MustGet() != "hello" {
 		t.Error("wrong value")
 	}
+
+	var stamp Val[time.Time]
+	if err := stamp.Scan("2016-01-27 06:03:17"); err != nil {
+		t.Error(err)
+	}
+	if !stamp.MustGet().Equal(time.Unix(1453874597, 0)) {
+		t.Error("wrong time:", stamp.MustGet())
+	}
+
+	var decimal Val[float64]
+	if err := decimal.Scan("123.45"); err != nil {
+		t.Error(err)
+	}
+	if decimal.MustGet() != 123.45 {
+		t.Error("wrong value:", decimal.MustGet())
+	}
+
+	var integer Val[int]
+	if err := integer.Scan("100"); err != nil {
+		t.Error(err)
+	}
+	if integer.MustGet() != 100 {
+		t.Error("wrong value:", integer.MustGet())
+	}
+	if err := integer.Scan([]byte("200.00")); err != nil {
+		t.Error(err)
+	}
+	if integer.MustGet() != 200 {
+		t.Error("wrong value:", integer.MustGet())
+	}
+	if err := integer.Scan("1.5"); err == nil {
+		t.Error("should not truncate a fraction")
+	}
+}
+
+func TestScanNull(t *testing.T) {
+	t.Parallel()
+
+	var val Val[int]
+	if err := val.Scan(nil); err == nil {
+		t.Error("omit should reject NULL")
+	}
+	checkState(t, val, StateUnset)
+
+	val = From(5)
+	if err := val.Scan(nil); err == nil {
+		t.Error("omit should reject NULL")
+	}
+	if val.MustGet() != 5 {
+		t.Error("a rejected NULL should leave the value alone")
+	}
+
+	// A nil Valuer is resolved to NULL before the state is decided.
+	if err := val.Scan((*valuerImplementation)(nil)); err == nil {
+		t.Error("omit should reject a Valuer returning NULL")
+	}
 }
 
 type valuerImplementation struct{}
@@ -386,6 +1476,50 @@ func TestValue(t *testing.T) {
 	}
 }
 
+func TestWithState(t *testing.T) {
+	t.Parallel()
+
+	val := From(5)
+	unset := val.WithState(StateUnset)
+	checkState(t, unset, StateUnset)
+	checkState(t, val, StateSet)
+	if unset.value != 5 {
+		t.Error("payload was not kept:", unset.value)
+	}
+
+	set := unset.WithState(StateSet)
+	checkState(t, set, StateSet)
+	if set.MustGet() != 5 {
+		t.Error("wrong value:", set.MustGet())
+	}
+}
+
+func TestStateByte(t *testing.T) {
+	t.Parallel()
+
+	if From(5).StateByte() != 1 {
+		t.Error("set should be 1")
+	}
+	if (Val[int]{}).StateByte() != 0 {
+		t.Error("unset should be 0")
+	}
+
+	val := From(5)
+	if err := val.SetStateByte(0); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateUnset)
+	if err := val.SetStateByte(1); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateSet)
+
+	if err := val.SetStateByte(2); err == nil {
+		t.Error("expected an error for an invalid byte")
+	}
+	checkState(t, val, StateSet)
+}
+
 func TestStateStringer(t *testing.T) {
 	t.Parallel()
 
@@ -405,6 +1539,34 @@ func TestStateStringer(t *testing.T) {
 	_ = state(99).String()
 }
 
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	if Key(Val[int]{}) == Key(From(0)) {
+		t.Error("unset and set zero should have different keys")
+	}
+	if Key(From(5)) != Key(From(5)) {
+		t.Error("equal set values should have equal keys")
+	}
+	if Key(From(5)) == Key(From(6)) {
+		t.Error("different set values should have different keys")
+	}
+	if Key(From(5).WithState(StateUnset)) != Key(Val[int]{}) {
+		t.Error("unset values should share a key whatever their payload")
+	}
+
+	groups := map[struct {
+		Set   bool
+		Value string
+	}]int{}
+	for _, v := range []Val[string]{From("a"), {}, From("a"), From(""), {}} {
+		groups[Key(v)]++
+	}
+	if len(groups) != 3 || groups[Key(From("a"))] != 2 || groups[Key(Val[string]{})] != 2 {
+		t.Error("wrong groups:", groups)
+	}
+}
+
 func TestEqual(t *testing.T) {
 	t.Parallel()
 
@@ -430,6 +1592,106 @@ func TestEqual(t *testing.T) {
 	}
 }
 
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	var unset Val[int]
+	tests := []struct {
+		a, b Val[int]
+		want int
+	}{
+		{unset, unset, 0},
+		{unset, From(0), -1},
+		{From(0), unset, 1},
+		{From(1), From(2), -1},
+		{From(2), From(1), 1},
+		{From(2), From(2), 0},
+	}
+
+	for i, test := range tests {
+		if got := Compare(test.a, test.b); got != test.want {
+			t.Errorf("%d: want %d, got %d", i, test.want, got)
+		}
+	}
+}
+
+func TestInRange(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []int{1, 5, 10} {
+		if err := InRange(From(v), 1, 10); err != nil {
+			t.Errorf("%d: %v", v, err)
+		}
+	}
+	if err := InRange(From(0), 1, 10); err == nil {
+		t.Error("expected an error below min")
+	}
+	if err := InRange(From(11), 1, 10); err == nil {
+		t.Error("expected an error above max")
+	} else if err.Error() != "value 11 is out of range [1, 10]" {
+		t.Error("wrong error:", err)
+	}
+	if err := InRange(Val[int]{}, 1, 10); err != nil {
+		t.Error("unset should be valid:", err)
+	}
+}
+
+func TestEqualConstantTime(t *testing.T) {
+	t.Parallel()
+
+	if !From("secret").EqualConstantTime(From("secret")) {
+		t.Error("should be equal")
+	}
+	if From("secret").EqualConstantTime(From("secreT")) {
+		t.Error("should not be equal")
+	}
+	if From("secret").EqualConstantTime(From("longer secret")) {
+		t.Error("should not be equal")
+	}
+	if !From([]byte("key")).EqualConstantTime(From([]byte("key"))) {
+		t.Error("should be equal")
+	}
+
+	if !(Val[string]{}).EqualConstantTime(Val[string]{}) {
+		t.Error("unset values should be equal")
+	}
+	if From("").EqualConstantTime(Val[string]{}) {
+		t.Error("set should not equal unset")
+	}
+	if (Val[[]byte]{}).EqualConstantTime(From([]byte{})) {
+		t.Error("unset should not equal set")
+	}
+
+	if From(5).EqualConstantTime(From(5)) {
+		t.Error("unsupported payloads should never be equal")
+	}
+}
+
+func TestEqualFunc(t *testing.T) {
+	t.Parallel()
+
+	a := Val[[]int]{}
+	b := Val[[]int]{}
+	if !EqualFunc(a, b, slices.Equal) {
+		t.Error("should be equal")
+	}
+
+	a.Set([]int{1, 2})
+	if EqualFunc(a, b, slices.Equal) {
+		t.Error("should not be equal")
+	}
+
+	b.Set([]int{1, 2})
+	if !EqualFunc(a, b, slices.Equal) {
+		t.Error("should be equal")
+	}
+
+	b.Set([]int{2, 1})
+	if EqualFunc(a, b, slices.Equal) {
+		t.Error("should not be equal")
+	}
+}
+
 func checkState[T any](t *testing.T, val Val[T], want state) {
 	t.Helper()
 
@@ -450,3 +1712,88 @@ func checkJSON[T any](t *testing.T, v Val[T], s string) {
 		t.Errorf("expect: %s, got: %s", s, b)
 	}
 }
+
+func BenchmarkValue(b *testing.B) {
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		val := From("hello")
+		for b.Loop() {
+			_, _ = val.Value()
+		}
+	})
+	b.Run("int64", func(b *testing.B) {
+		b.ReportAllocs()
+		val := From(int64(5))
+		for b.Loop() {
+			_, _ = val.Value()
+		}
+	})
+	b.Run("time", func(b *testing.B) {
+		b.ReportAllocs()
+		val := From(time.Now())
+		for b.Loop() {
+			_, _ = val.Value()
+		}
+	})
+	b.Run("int", func(b *testing.B) {
+		b.ReportAllocs()
+		val := From(5)
+		for b.Loop() {
+			_, _ = val.Value()
+		}
+	})
+}
+
+func BenchmarkIsZero(b *testing.B) {
+	b.Run("int", func(b *testing.B) {
+		v := From(5)
+		for b.Loop() {
+			_ = v.IsZero()
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		v := From([]int{1})
+		for b.Loop() {
+			_ = v.IsZero()
+		}
+	})
+}
+
+func TestUnmarshalJSONReuse(t *testing.T) {
+	t.Parallel()
+
+	slice := From(make([]int, 0, 4))
+	backing := &slice.MustGet()[:1][0]
+	if err := slice.UnmarshalJSON([]byte(`[1,2,3]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := slice.MustGet(); !slices.Equal(got, []int{1, 2, 3}) || &got[0] != backing {
+		t.Error("expected the backing array to be reused:", got)
+	}
+
+	m := From(map[string]int{"kept": 1})
+	if err := m.UnmarshalJSON([]byte(`{"a":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.MustGet(); len(got) != 2 || got["kept"] != 1 || got["a"] != 2 {
+		t.Error("expected the entries to be merged:", got)
+	}
+
+	held := []int{9, 9, 9}
+	unset := From(held).WithState(StateUnset)
+	if err := unset.UnmarshalJSON([]byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+	if held[0] != 9 {
+		t.Error("an unset value should not decode into its old payload:", held)
+	}
+}
+
+func BenchmarkUnmarshalJSONReuse(b *testing.B) {
+	data := []byte(`[1,2,3,4,5,6,7,8]`)
+	b.ReportAllocs()
+	var v Val[[]int]
+	for b.Loop() {
+		_ = v.UnmarshalJSON(data)
+	}
+}
@@ -0,0 +1,27 @@
+package omit
+
+import (
+	"strconv"
+)
+
+// ParseInt parses s as a base 10 int64, the value is unset if s is not a
+// valid integer.
+func ParseInt(s string) Val[int64] {
+	return FromCond(nilErr(strconv.ParseInt(s, 10, 64)))
+}
+
+// ParseFloat parses s as a float64, the value is unset if s is not a valid
+// float.
+func ParseFloat(s string) Val[float64] {
+	return FromCond(nilErr(strconv.ParseFloat(s, 64)))
+}
+
+// ParseBool parses s with strconv.ParseBool, the value is unset if s is not
+// a valid bool.
+func ParseBool(s string) Val[bool] {
+	return FromCond(nilErr(strconv.ParseBool(s)))
+}
+
+func nilErr[T any](val T, err error) (T, bool) {
+	return val, err == nil
+}
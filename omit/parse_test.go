@@ -0,0 +1,29 @@
+package omit
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	if ParseInt("-42").MustGet() != -42 {
+		t.Error("wrong value")
+	}
+	checkState(t, ParseInt("4.2"), StateUnset)
+	checkState(t, ParseInt(""), StateUnset)
+	checkState(t, ParseInt("99999999999999999999"), StateUnset)
+
+	if ParseFloat("1.5").MustGet() != 1.5 {
+		t.Error("wrong value")
+	}
+	checkState(t, ParseFloat("one"), StateUnset)
+
+	if !ParseBool("true").MustGet() {
+		t.Error("wrong value")
+	}
+	if ParseBool("0").MustGet() {
+		t.Error("wrong value")
+	}
+	checkState(t, ParseBool("yes"), StateUnset)
+}
@@ -0,0 +1,131 @@
+// Package patch applies structs of omit.Val fields onto plain structs, which
+// is the usual shape of a PATCH request handler:
+//
+//	type UserPatch struct {
+//		Name  omit.Val[string]
+//		Email omit.Val[string]
+//	}
+//
+//	if err := patch.Apply(&user, req); err != nil {
+//		...
+//	}
+package patch
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// optional is implemented by omit.Val regardless of its type parameter.
+type optional interface {
+	IsValue() bool
+	IsUnset() bool
+}
+
+var optionalType = reflect.TypeFor[optional]()
+
+// Apply copies the set omit.Val fields of patch (a struct or pointer to one)
+// onto the fields of the same Go name in dst, which must be a pointer to a
+// struct. Unset fields, fields of patch that are not omit.Val and fields with
+// no counterpart in dst are skipped. Fields promoted from embedded structs,
+// or pointers to them, are matched like any other. A nil embedded pointer in
+// patch contributes nothing, while one in dst is allocated when a field
+// promoted through it is patched.
+//
+// The value of a set field is assigned to the dst field, or the omit.Val
+// itself is when the dst field has the same type as the patch field. Any other
+// mismatch between the two is an error. dst may be partially patched when an
+// error is returned.
+func Apply(dst any, patch any) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("patch destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+
+	pv := reflect.ValueOf(patch)
+	for pv.Kind() == reflect.Pointer {
+		if pv.IsNil() {
+			return fmt.Errorf("cannot apply nil patch %T", patch)
+		}
+		pv = pv.Elem()
+	}
+	if pv.Kind() != reflect.Struct {
+		return fmt.Errorf("patch must be a struct, got %T", patch)
+	}
+
+	return applyFields(dv.Elem(), pv)
+}
+
+func applyFields(dv, pv reflect.Value) error {
+	pt := pv.Type()
+	for i := 0; i < pt.NumField(); i++ {
+		field := pt.Field(i)
+		fv := pv.Field(i)
+
+		if !field.Type.Implements(optionalType) {
+			if !field.Anonymous {
+				continue
+			}
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := applyFields(dv, fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.IsExported() || !fv.Interface().(optional).IsValue() {
+			continue
+		}
+
+		targetField, ok := dv.Type().FieldByName(field.Name)
+		if !ok || !targetField.IsExported() {
+			continue
+		}
+
+		val := fv
+		if targetField.Type != field.Type {
+			val = fv.MethodByName("Get").Call(nil)[0]
+			if !val.Type().AssignableTo(targetField.Type) {
+				return fmt.Errorf("cannot apply patch field %s of type %s to field of type %s",
+					field.Name, field.Type, targetField.Type)
+			}
+		}
+
+		target, ok := fieldByIndex(dv, targetField.Index)
+		if !ok || !target.CanSet() {
+			continue
+		}
+		target.Set(val)
+	}
+
+	return nil
+}
+
+// fieldByIndex returns the field of v at index, allocating any nil embedded
+// struct pointers on the way to it. It reports false if one of those cannot
+// be set, as is the case for unexported ones.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	if f, err := v.FieldByIndexErr(index); err == nil {
+		return f, true
+	}
+
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,165 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/blink-io/opt/omit"
+)
+
+type user struct {
+	Name    string
+	Email   string
+	Age     int
+	Tags    []string
+	Nick    omit.Val[string]
+	Created string
+}
+
+type audit struct {
+	Created omit.Val[string]
+}
+
+type userPatch struct {
+	audit
+
+	Name    omit.Val[string]
+	Email   omit.Val[string]
+	Age     omit.Val[int]
+	Tags    omit.Val[[]string]
+	Nick    omit.Val[string]
+	Unknown omit.Val[string]
+	Plain   string
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	u := user{Name: "old", Email: "old@example.com", Age: 30}
+	p := userPatch{
+		audit:   audit{Created: omit.From("today")},
+		Name:    omit.From("new"),
+		Tags:    omit.From([]string{"a"}),
+		Nick:    omit.From("nick"),
+		Unknown: omit.From("ignored"),
+		Plain:   "ignored",
+	}
+
+	if err := Apply(&u, p); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "new" {
+		t.Error("set fields should be applied:", u.Name)
+	}
+	if u.Email != "old@example.com" || u.Age != 30 {
+		t.Error("unset fields should be left alone:", u)
+	}
+	if len(u.Tags) != 1 || u.Tags[0] != "a" {
+		t.Error("wrong tags:", u.Tags)
+	}
+	if u.Nick.MustGet() != "nick" {
+		t.Error("a Val field should be copied whole:", u.Nick)
+	}
+	if u.Created != "today" {
+		t.Error("embedded patch fields should be applied:", u.Created)
+	}
+
+	if err := Apply(&u, &userPatch{Age: omit.From(31)}); err != nil {
+		t.Fatal(err)
+	}
+	if u.Age != 31 {
+		t.Error("a pointer patch should be applied:", u.Age)
+	}
+}
+
+type Base struct {
+	ID      int
+	Updated string
+}
+
+type record struct {
+	*Base
+	Title string
+}
+
+type hidden struct {
+	*secret
+	Title string
+}
+
+type secret struct {
+	ID int
+}
+
+type BasePatch struct {
+	ID omit.Val[int]
+}
+
+type recordPatch struct {
+	*BasePatch
+	Title omit.Val[string]
+}
+
+func TestApplyEmbeddedPointers(t *testing.T) {
+	t.Parallel()
+
+	var r record
+	if err := Apply(&r, recordPatch{BasePatch: &BasePatch{ID: omit.From(7)}}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Base == nil || r.ID != 7 {
+		t.Error("fields of an embedded patch pointer should be applied to a nil dst pointer:", r.Base)
+	}
+
+	existing := &Base{ID: 1, Updated: "kept"}
+	r = record{Base: existing}
+	if err := Apply(&r, recordPatch{BasePatch: &BasePatch{ID: omit.From(8)}, Title: omit.From("t")}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Base != existing || r.ID != 8 || r.Updated != "kept" || r.Title != "t" {
+		t.Error("an existing dst pointer should be patched in place:", r, *r.Base)
+	}
+
+	r = record{}
+	if err := Apply(&r, recordPatch{Title: omit.From("only")}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Base != nil || r.Title != "only" {
+		t.Error("a nil patch pointer should contribute nothing:", r)
+	}
+
+	var h hidden
+	if err := Apply(&h, BasePatch{ID: omit.From(9)}); err != nil {
+		t.Fatal(err)
+	}
+	if h.secret != nil {
+		t.Error("an unexported dst pointer cannot be allocated and should be skipped")
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	t.Parallel()
+
+	type mismatch struct {
+		Age omit.Val[string]
+	}
+
+	u := user{Age: 30}
+	if err := Apply(&u, mismatch{Age: omit.From("thirty")}); err == nil {
+		t.Error("expected an error for mismatched types")
+	} else if err.Error() != "cannot apply patch field Age of type omit.Val[string] to field of type int" {
+		t.Error("wrong error:", err)
+	}
+	if err := Apply(&u, mismatch{}); err != nil {
+		t.Error("an unset mismatched field should be skipped:", err)
+	}
+
+	if err := Apply(u, userPatch{}); err == nil {
+		t.Error("expected an error for a non-pointer destination")
+	}
+	if err := Apply(&u, 5); err == nil {
+		t.Error("expected an error for a non-struct patch")
+	}
+	if err := Apply(&u, (*userPatch)(nil)); err == nil {
+		t.Error("expected an error for a nil patch")
+	}
+}
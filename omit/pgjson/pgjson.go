@@ -0,0 +1,63 @@
+// Package pgjson provides an omit.Val that is stored in JSON typed database
+// columns, such as Postgres json and jsonb, so that a struct payload can be
+// scanned and written without a wrapper type for each struct.
+package pgjson
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/blink-io/opt"
+	"github.com/blink-io/opt/omit"
+)
+
+// Val is an omit.Val whose payload is kept in the database as JSON. All the
+// methods of omit.Val are available on it, only Scan and Value differ.
+type Val[T any] struct {
+	omit.Val[T]
+}
+
+// From a value which is considered 'set'.
+func From[T any](val T) Val[T] {
+	return Val[T]{Val: omit.From(val)}
+}
+
+// Wrap an omit.Val so that it is stored as JSON.
+func Wrap[T any](val omit.Val[T]) Val[T] {
+	return Val[T]{Val: val}
+}
+
+// Scan implements the sql.Scanner interface by unmarshaling a []byte or
+// string source as JSON into T with opt.JSONUnmarshal. A NULL column makes the
+// value unset, the counterpart of Value.
+func (v *Val[T]) Scan(value any) error {
+	var data []byte
+	switch src := value.(type) {
+	case nil:
+		v.Unset()
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("cannot scan %T into json value", value)
+	}
+
+	var val T
+	if err := opt.JSONUnmarshal(data, &val); err != nil {
+		return err
+	}
+	v.Set(val)
+	return nil
+}
+
+// Value implements the driver.Valuer interface by marshaling the value as
+// JSON with opt.JSONMarshal. An unset value is written as NULL.
+func (v Val[T]) Value() (driver.Value, error) {
+	val, ok := v.Get()
+	if !ok {
+		return nil, nil
+	}
+	return opt.JSONMarshal(val)
+}
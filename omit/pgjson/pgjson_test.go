@@ -0,0 +1,84 @@
+package pgjson
+
+import (
+	"testing"
+
+	"github.com/blink-io/opt"
+	"github.com/blink-io/opt/omit"
+)
+
+type settings struct {
+	Theme string   `json:"theme"`
+	Tags  []string `json:"tags"`
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	var val Val[settings]
+	if err := val.Scan([]byte(`{"theme":"dark","tags":["a"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	got := val.MustGet()
+	if got.Theme != "dark" || len(got.Tags) != 1 || got.Tags[0] != "a" {
+		t.Error("wrong value:", got)
+	}
+
+	if err := val.Scan(`{"theme":"light"}`); err != nil {
+		t.Fatal(err)
+	}
+	if val.MustGet().Theme != "light" {
+		t.Error("wrong value:", val.MustGet())
+	}
+
+	if err := val.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if !val.IsUnset() {
+		t.Error("null should be unset")
+	}
+
+	val = From(settings{Theme: "kept"})
+	if err := val.Scan([]byte(`{"theme":`)); err == nil {
+		t.Error("expected an error for bad json")
+	}
+	if err := val.Scan(5); err == nil {
+		t.Error("expected an error for a non-text source")
+	}
+	if val.MustGet().Theme != "kept" {
+		t.Error("failed scans should leave the value alone")
+	}
+}
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	v, err := From(settings{Theme: "dark"}).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := v.([]byte); !ok || string(b) != `{"theme":"dark","tags":null}` {
+		t.Errorf("wrong value: %#v", v)
+	}
+
+	v, err = Wrap(omit.Val[settings]{}).Value()
+	if err != nil || v != nil {
+		t.Error("unset should be NULL:", v, err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := opt.JSONMarshal(From(settings{Theme: "dark"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var val Val[settings]
+	if err := opt.JSONUnmarshal(b, &val); err != nil {
+		t.Fatal(err)
+	}
+	if val.MustGet().Theme != "dark" {
+		t.Error("wrong value:", val.MustGet())
+	}
+}
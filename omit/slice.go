@@ -0,0 +1,184 @@
+package omit
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/blink-io/opt"
+	"github.com/blink-io/opt/internal/globaldata"
+)
+
+// MarshalNDJSON writes vals to w as newline-delimited JSON, one value per
+// line. Unset values are skipped if skipUnset is true, otherwise they are
+// written as a null line.
+func MarshalNDJSON[T any](w io.Writer, vals []Val[T], skipUnset bool) error {
+	for _, v := range vals {
+		var line []byte
+		if v.state == StateSet {
+			b, err := opt.MarshalValueJSON(v.value)
+			if err != nil {
+				return err
+			}
+			line = b
+		} else if skipUnset {
+			continue
+		} else {
+			line = globaldata.JSONNull
+		}
+
+		// Clip so the newline never lands in a shared backing array.
+		if _, err := w.Write(append(line[:len(line):len(line)], '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Find returns the first set value in vals whose value satisfies pred, or an
+// unset value if there is none. pred is never called for unset values.
+func Find[T any](vals []Val[T], pred func(T) bool) Val[T] {
+	for _, v := range vals {
+		if v.state == StateSet && pred(v.value) {
+			return v
+		}
+	}
+	return Val[T]{}
+}
+
+// ZipKeys pairs keys with vals by position and returns the pairs of the set
+// entries in order, for building ordered output where unset entries are left
+// out. It is an error if keys and vals have different lengths.
+func ZipKeys[T any](keys []string, vals []Val[T]) ([]struct {
+	Key   string
+	Value T
+}, error) {
+	if len(keys) != len(vals) {
+		return nil, fmt.Errorf("cannot zip %d keys with %d values", len(keys), len(vals))
+	}
+
+	pairs := make([]struct {
+		Key   string
+		Value T
+	}, 0, len(vals))
+	for i, v := range vals {
+		if v.state == StateSet {
+			pairs = append(pairs, struct {
+				Key   string
+				Value T
+			}{Key: keys[i], Value: v.value})
+		}
+	}
+	return pairs, nil
+}
+
+// GetAll returns the values of the set entries in vals in order, skipping
+// unset ones. The result is never nil, so it marshals as [] rather than null
+// when nothing is set.
+func GetAll[T any](vals []Val[T]) []T {
+	out := make([]T, 0, len(vals))
+	for _, v := range vals {
+		if v.state == StateSet {
+			out = append(out, v.value)
+		}
+	}
+	return out
+}
+
+// SetAll wraps each of vals with From.
+func SetAll[T any](vals []T) []Val[T] {
+	out := make([]Val[T], len(vals))
+	for i, v := range vals {
+		out[i] = From(v)
+	}
+	return out
+}
+
+// CollectSeq drains seq and collects the values of its set entries in order.
+// The result is set if seq yielded at least one set value and unset if it
+// was empty or yielded only unset values, and so a set result never holds an
+// empty slice.
+func CollectSeq[T any](seq iter.Seq[Val[T]]) Val[[]T] {
+	var out []T
+	for v := range seq {
+		if v.state == StateSet {
+			out = append(out, v.value)
+		}
+	}
+	if out == nil {
+		return Val[[]T]{}
+	}
+	return From(out)
+}
+
+// PackBools packs vals into two bitmaps of (len(vals)+7)/8 bytes each, for
+// compactly storing dense optional booleans. Bit i of present is set if
+// vals[i] is set, and bit i of bits holds its value. Bits are numbered from
+// the least significant bit of the first byte. The value bits of unset
+// entries are always zero.
+func PackBools(vals []Val[bool]) (bits, present []byte) {
+	n := (len(vals) + 7) / 8
+	bits = make([]byte, n)
+	present = make([]byte, n)
+	for i, v := range vals {
+		if v.state != StateSet {
+			continue
+		}
+		mask := byte(1) << (i % 8)
+		present[i/8] |= mask
+		if v.value {
+			bits[i/8] |= mask
+		}
+	}
+	return bits, present
+}
+
+// UnpackBools reverses PackBools, returning the first n values. It is an
+// error if either bitmap is too short to hold n values.
+func UnpackBools(bits, present []byte, n int) ([]Val[bool], error) {
+	need := (n + 7) / 8
+	if n < 0 || len(bits) < need || len(present) < need {
+		return nil, fmt.Errorf("cannot unpack %d bools from %d value and %d presence bytes", n, len(bits), len(present))
+	}
+
+	vals := make([]Val[bool], n)
+	for i := range vals {
+		mask := byte(1) << (i % 8)
+		if present[i/8]&mask != 0 {
+			vals[i] = From(bits[i/8]&mask != 0)
+		}
+	}
+	return vals, nil
+}
+
+// UniformState returns the state shared by all of vals and true, or false if
+// their states are mixed. An empty slice is trivially uniform and reports
+// StateUnset.
+func UniformState[T any](vals []Val[T]) (state, bool) {
+	if len(vals) == 0 {
+		return StateUnset, true
+	}
+
+	s := vals[0].state
+	for _, v := range vals[1:] {
+		if v.state != s {
+			return s, false
+		}
+	}
+	return s, true
+}
+
+// Slice is a slice of values that implements sort.Interface, ordering unset
+// values first and set values by Compare.
+type Slice[T cmp.Ordered] []Val[T]
+
+// Len implements sort.Interface.
+func (s Slice[T]) Len() int { return len(s) }
+
+// Less implements sort.Interface.
+func (s Slice[T]) Less(i, j int) bool { return Compare(s[i], s[j]) < 0 }
+
+// Swap implements sort.Interface.
+func (s Slice[T]) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,166 @@
+package omit
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestMarshalNDJSON(t *testing.T) {
+	t.Parallel()
+
+	vals := []Val[string]{From("a"), {}, From("b")}
+
+	buf := &bytes.Buffer{}
+	if err := MarshalNDJSON(buf, vals, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "\"a\"\n\"b\"\n" {
+		t.Errorf("wrong output: %q", got)
+	}
+
+	buf.Reset()
+	if err := MarshalNDJSON(buf, vals, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "\"a\"\nnull\n\"b\"\n" {
+		t.Errorf("wrong output: %q", got)
+	}
+
+	buf.Reset()
+	if err := MarshalNDJSON[string](buf, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Error("expected no output")
+	}
+}
+
+func TestSliceSort(t *testing.T) {
+	t.Parallel()
+
+	s := Slice[int]{From(3), {}, From(1), From(2), {}}
+	sort.Sort(s)
+
+	want := Slice[int]{{}, {}, From(1), From(2), From(3)}
+	for i := range want {
+		if !Equal(s[i], want[i]) {
+			t.Errorf("%d: want %v, got %v", i, want[i], s[i])
+		}
+	}
+}
+
+func TestUniformState(t *testing.T) {
+	t.Parallel()
+
+	if s, ok := UniformState([]Val[int]{From(1), From(2)}); !ok || s != StateSet {
+		t.Error("expected uniformly set, got:", s, ok)
+	}
+	if s, ok := UniformState([]Val[int]{{}, {}}); !ok || s != StateUnset {
+		t.Error("expected uniformly unset, got:", s, ok)
+	}
+	if _, ok := UniformState([]Val[int]{From(1), {}, From(2)}); ok {
+		t.Error("expected mixed states")
+	}
+	if s, ok := UniformState[int](nil); !ok || s != StateUnset {
+		t.Error("expected empty to be uniformly unset, got:", s, ok)
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	even := func(i int) bool { return i%2 == 0 }
+
+	if Find([]Val[int]{From(1), {}, From(4), From(6)}, even).MustGet() != 4 {
+		t.Error("wrong value")
+	}
+	checkState(t, Find([]Val[int]{From(1), From(3)}, even), StateUnset)
+
+	called := false
+	checkState(t, Find([]Val[int]{{}, {}}, func(int) bool {
+		called = true
+		return true
+	}), StateUnset)
+	if called {
+		t.Error("pred should not be called for unset values")
+	}
+}
+
+func TestGetAllSetAll(t *testing.T) {
+	t.Parallel()
+
+	vals := []Val[int]{From(1), {}, From(3)}
+	if got := GetAll(vals); !slices.Equal(got, []int{1, 3}) {
+		t.Error("wrong values:", got)
+	}
+
+	got := GetAll([]Val[int]{{}, {}})
+	if got == nil || len(got) != 0 {
+		t.Error("expected a non-nil empty slice:", got)
+	}
+	if b, err := json.Marshal(got); err != nil || string(b) != "[]" {
+		t.Error("wrong json:", string(b), err)
+	}
+
+	wrapped := SetAll([]string{"a", "b"})
+	if len(wrapped) != 2 || wrapped[0].MustGet() != "a" || wrapped[1].MustGet() != "b" {
+		t.Error("wrong wrapped values:", wrapped)
+	}
+}
+
+func TestCollectSeq(t *testing.T) {
+	t.Parallel()
+
+	got := CollectSeq(slices.Values([]Val[int]{From(1), {}, From(3), {}}))
+	checkState(t, got, StateSet)
+	if !slices.Equal(got.MustGet(), []int{1, 3}) {
+		t.Error("wrong values:", got.MustGet())
+	}
+
+	checkState(t, CollectSeq(slices.Values([]Val[int]{{}, {}})), StateUnset)
+	checkState(t, CollectSeq(slices.Values([]Val[int]{})), StateUnset)
+}
+
+func TestZipKeys(t *testing.T) {
+	t.Parallel()
+
+	pairs, err := ZipKeys([]string{"a", "b", "c"}, []Val[int]{From(1), {}, From(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 || pairs[0].Key != "a" || pairs[0].Value != 1 || pairs[1].Key != "c" || pairs[1].Value != 3 {
+		t.Error("wrong pairs:", pairs)
+	}
+
+	if _, err := ZipKeys([]string{"a"}, []Val[int]{From(1), From(2)}); err == nil {
+		t.Error("expected an error for mismatched lengths")
+	}
+}
+
+func TestPackBools(t *testing.T) {
+	t.Parallel()
+
+	vals := []Val[bool]{From(true), From(false), {}, From(true), {}, {}, From(false), From(true), From(true), {}}
+	bits, present := PackBools(vals)
+	if !bytes.Equal(bits, []byte{0b10001001, 0b01}) || !bytes.Equal(present, []byte{0b11001011, 0b01}) {
+		t.Errorf("wrong bitmaps: %08b %08b", bits, present)
+	}
+
+	got, err := UnpackBools(bits, present, len(vals))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, vals) {
+		t.Error("wrong values:", got)
+	}
+
+	if bits, present := PackBools(nil); len(bits) != 0 || len(present) != 0 {
+		t.Error("expected empty bitmaps:", bits, present)
+	}
+	if _, err := UnpackBools(bits, present[:1], len(vals)); err == nil {
+		t.Error("expected an error for a short bitmap")
+	}
+}
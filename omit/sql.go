@@ -0,0 +1,79 @@
+package omit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ScanRow scans a single column result into a Val. A query that returns no
+// rows produces an unset value rather than sql.ErrNoRows, any other error is
+// returned as-is.
+func ScanRow[T any](row *sql.Row) (Val[T], error) {
+	var val Val[T]
+	if err := row.Scan(&val); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Val[T]{}, nil
+		}
+		return Val[T]{}, err
+	}
+	return val, nil
+}
+
+// ScanAll scans every row of rows into a T, which must be a struct of Val
+// fields, and closes rows when done. Columns are matched to fields by their
+// db tags (or Go names without one) and NULL columns leave their field unset.
+// Every column must have a field, fields without a column stay unset.
+//
+// ScanAll keeps no state between calls so it may be used from many goroutines
+// at once, though as with sql.Rows itself each rows must only be used by one.
+func ScanAll[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []T{}
+	dest := make([]any, len(cols))
+	for rows.Next() {
+		var row T
+		fields, err := valFields(&row, WithTags("db"))
+		if err != nil {
+			return nil, err
+		}
+
+		byName := make(map[string]valField, len(fields))
+		for _, f := range fields {
+			byName[f.name] = f
+		}
+		for i, col := range cols {
+			f, ok := byName[col]
+			if !ok {
+				return nil, fmt.Errorf("cannot scan column %q, %T has no field for it", col, row)
+			}
+			dest[i] = nullAsUnset{f.ptr()}
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+
+	return out, rows.Err()
+}
+
+// nullAsUnset scans NULL into a Val as unset, where Scan alone would refuse.
+type nullAsUnset struct {
+	val omittablePtr
+}
+
+func (n nullAsUnset) Scan(value any) error {
+	if value == nil {
+		n.val.Unset()
+		return nil
+	}
+	return n.val.(sql.Scanner).Scan(value)
+}
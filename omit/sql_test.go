@@ -0,0 +1,203 @@
+package omit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeDriver serves canned results keyed by query text so the database/sql
+// machinery can be exercised without a real database.
+type fakeDriver struct {
+	mut     sync.Mutex
+	results map[string]fakeResult
+}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+var testDriver = &fakeDriver{results: map[string]fakeResult{}}
+
+func init() {
+	sql.Register("omitfake", testDriver)
+}
+
+// openFakeDB registers the results for query and returns a db to run it on.
+func openFakeDB(t *testing.T, query string, result fakeResult) *sql.DB {
+	t.Helper()
+
+	testDriver.mut.Lock()
+	testDriver.results[query] = result
+	testDriver.mut.Unlock()
+
+	db, err := sql.Open("omitfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{d: c.d, query: query}, nil
+}
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.mut.Lock()
+	result, ok := s.d.results[s.query]
+	s.d.mut.Unlock()
+	if !ok {
+		return nil, errors.New("unknown query: " + s.query)
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func TestScanRow(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db := openFakeDB(t, "select name", fakeResult{
+		columns: []string{"name"},
+		rows:    [][]driver.Value{{"hello"}},
+	})
+	val, err := ScanRow[string](db.QueryRowContext(ctx, "select name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateSet)
+	if val.MustGet() != "hello" {
+		t.Error("wrong value:", val.MustGet())
+	}
+
+	db = openFakeDB(t, "select nothing", fakeResult{columns: []string{"name"}})
+	val, err = ScanRow[string](db.QueryRowContext(ctx, "select nothing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateUnset)
+
+	queryErr := errors.New("broken")
+	db = openFakeDB(t, "select broken", fakeResult{err: queryErr})
+	if _, err = ScanRow[string](db.QueryRowContext(ctx, "select broken")); !errors.Is(err, queryErr) {
+		t.Error("expected the query error, got:", err)
+	}
+}
+
+func TestScanAll(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID    Val[int64]  `db:"id"`
+		Name  Val[string] `db:"name"`
+		Email Val[string]
+		Extra Val[string] `db:"extra"`
+	}
+
+	db := openFakeDB(t, "select users", fakeResult{
+		columns: []string{"id", "name", "Email"},
+		rows: [][]driver.Value{
+			{int64(1), "alice", "alice@example.com"},
+			{int64(2), nil, nil},
+		},
+	})
+	rows, err := db.Query("select users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := ScanAll[user](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatal("wrong number of users:", len(users))
+	}
+
+	checkState(t, users[0].ID, StateSet)
+	checkState(t, users[0].Name, StateSet)
+	checkState(t, users[0].Email, StateSet)
+	checkState(t, users[0].Extra, StateUnset)
+	if users[0].ID.MustGet() != 1 || users[0].Name.MustGet() != "alice" || users[0].Email.MustGet() != "alice@example.com" {
+		t.Error("wrong values:", users[0])
+	}
+
+	checkState(t, users[1].ID, StateSet)
+	checkState(t, users[1].Name, StateUnset)
+	checkState(t, users[1].Email, StateUnset)
+	if users[1].ID.MustGet() != 2 {
+		t.Error("wrong id:", users[1].ID)
+	}
+
+	db = openFakeDB(t, "select none", fakeResult{columns: []string{"id"}})
+	rows, err = db.Query("select none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err = ScanAll[user](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users == nil || len(users) != 0 {
+		t.Error("expected an empty slice:", users)
+	}
+
+	db = openFakeDB(t, "select unknown", fakeResult{
+		columns: []string{"id", "missing"},
+		rows:    [][]driver.Value{{int64(1), "x"}},
+	})
+	rows, err = db.Query("select unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ScanAll[user](rows); err == nil {
+		t.Error("expected an error for a column without a field")
+	}
+}
@@ -0,0 +1,71 @@
+package omit
+
+import (
+	"sync"
+)
+
+// Once lazily computes an optional value at most once. It is safe for
+// concurrent use and its zero value is ready to use. A Once must not be
+// copied after first use.
+type Once[T any] struct {
+	once sync.Once
+	val  Val[T]
+}
+
+// Get returns the value, calling init to compute it on the first call only.
+// If init reports false the value stays unset, init is still never called
+// again.
+func (o *Once[T]) Get(init func() (T, bool)) Val[T] {
+	o.once.Do(func() {
+		o.val = FromCond(init())
+	})
+	return o.val
+}
+
+// SyncVal is a Val that is safe for concurrent use, for optional values that
+// are shared and changed such as live configuration. Its methods mirror those
+// of Val and each one is atomic, though a Get followed by a Set is not. Its
+// zero value is unset and ready to use. A SyncVal must not be copied after
+// first use.
+type SyncVal[T any] struct {
+	mut sync.RWMutex
+	val Val[T]
+}
+
+// Load returns a copy of the current value.
+func (s *SyncVal[T]) Load() Val[T] {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.val
+}
+
+// Store replaces the current value with val.
+func (s *SyncVal[T]) Store(val Val[T]) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.val = val
+}
+
+// Swap replaces the current value with val and returns the old one.
+func (s *SyncVal[T]) Swap(val Val[T]) Val[T] {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	old := s.val
+	s.val = val
+	return old
+}
+
+// Get is Val.Get on the current value.
+func (s *SyncVal[T]) Get() (T, bool) {
+	return s.Load().Get()
+}
+
+// Set the value (and the state to 'set').
+func (s *SyncVal[T]) Set(val T) {
+	s.Store(From(val))
+}
+
+// Unset the value (state is set to 'unset').
+func (s *SyncVal[T]) Unset() {
+	s.Store(Val[T]{})
+}
@@ -0,0 +1,89 @@
+package omit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOnce(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	var once Once[string]
+	init := func() (string, bool) {
+		calls.Add(1)
+		return "hello", true
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if once.Get(init).MustGet() != "hello" {
+				t.Error("wrong value")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Error("init should run once, ran:", n)
+	}
+}
+
+func TestOnceUnset(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	var once Once[int]
+	init := func() (int, bool) {
+		calls++
+		return 5, false
+	}
+
+	checkState(t, once.Get(init), StateUnset)
+	checkState(t, once.Get(init), StateUnset)
+	if calls != 1 {
+		t.Error("init should not rerun after yielding unset, ran:", calls)
+	}
+}
+
+func TestSyncVal(t *testing.T) {
+	t.Parallel()
+
+	var s SyncVal[int]
+	checkState(t, s.Load(), StateUnset)
+
+	s.Set(5)
+	if v, ok := s.Get(); !ok || v != 5 {
+		t.Error("wrong value:", v, ok)
+	}
+	if old := s.Swap(From(6)); old.MustGet() != 5 {
+		t.Error("wrong old value:", old)
+	}
+	s.Unset()
+	checkState(t, s.Load(), StateUnset)
+	s.Store(From(7))
+	if s.Load().MustGet() != 7 {
+		t.Error("wrong value:", s.Load())
+	}
+
+	// Run with -race to check the guarantees.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.Set(i)
+			s.Unset()
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = s.Get()
+			_ = s.Swap(From(i))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,63 @@
+// Package tracked wraps omit.Val to record whether its value was ever read,
+// which helps find optional configuration fields that nothing uses.
+package tracked
+
+import (
+	"sync/atomic"
+
+	"github.com/blink-io/opt/omit"
+)
+
+// Tracked is an omit.Val that records whether its value was read through
+// Get, GetOr or MustGet. Checking the state with IsSet or IsUnset does not
+// count as a read. It is safe for concurrent use and its zero value is an
+// unset value that has not been read. A Tracked must not be copied after
+// first use.
+type Tracked[T any] struct {
+	val  omit.Val[T]
+	read atomic.Bool
+}
+
+// New creates a tracked value holding v.
+func New[T any](v omit.Val[T]) *Tracked[T] {
+	return &Tracked[T]{val: v}
+}
+
+// Get returns the value and whether it is set, marking it as read.
+func (t *Tracked[T]) Get() (T, bool) {
+	t.read.Store(true)
+	return t.val.Get()
+}
+
+// GetOr returns the value or fallback if it is unset, marking it as read.
+func (t *Tracked[T]) GetOr(fallback T) T {
+	t.read.Store(true)
+	return t.val.GetOr(fallback)
+}
+
+// MustGet returns the value or panics if it is unset, marking it as read.
+func (t *Tracked[T]) MustGet() T {
+	t.read.Store(true)
+	return t.val.MustGet()
+}
+
+// IsSet returns true if the value is set.
+func (t *Tracked[T]) IsSet() bool {
+	return t.val.IsValue()
+}
+
+// IsUnset returns true if the value is unset.
+func (t *Tracked[T]) IsUnset() bool {
+	return t.val.IsUnset()
+}
+
+// WasRead returns true if the value was ever read.
+func (t *Tracked[T]) WasRead() bool {
+	return t.read.Load()
+}
+
+// UnmarshalJSON implements json.Unmarshaler so that tracked values can be
+// loaded as part of a configuration struct. Loading is not a read.
+func (t *Tracked[T]) UnmarshalJSON(data []byte) error {
+	return t.val.UnmarshalJSON(data)
+}
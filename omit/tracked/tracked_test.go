@@ -0,0 +1,59 @@
+package tracked
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/blink-io/opt/omit"
+)
+
+func TestWasRead(t *testing.T) {
+	t.Parallel()
+
+	reads := map[string]func(*Tracked[int]){
+		"Get":     func(v *Tracked[int]) { v.Get() },
+		"GetOr":   func(v *Tracked[int]) { v.GetOr(0) },
+		"MustGet": func(v *Tracked[int]) { v.MustGet() },
+	}
+	for name, read := range reads {
+		v := New(omit.From(5))
+		if v.WasRead() {
+			t.Errorf("%s: should not be read yet", name)
+		}
+		read(v)
+		if !v.WasRead() {
+			t.Errorf("%s: should be read", name)
+		}
+	}
+
+	var unset Tracked[int]
+	if !unset.IsUnset() || unset.IsSet() || unset.WasRead() {
+		t.Error("checking the state should not count as a read")
+	}
+	if unset.GetOr(6) != 6 || !unset.WasRead() {
+		t.Error("reading an unset value should count as a read")
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	var config struct {
+		Port    Tracked[int]    `json:"port"`
+		Verbose Tracked[bool]   `json:"verbose"`
+		Name    Tracked[string] `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(`{"port":8080,"verbose":true}`), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Port.MustGet() != 8080 {
+		t.Error("wrong port")
+	}
+	if !config.Verbose.IsSet() || !config.Name.IsUnset() {
+		t.Error("wrong states")
+	}
+	if !config.Port.WasRead() || config.Verbose.WasRead() || config.Name.WasRead() {
+		t.Error("only port should be read")
+	}
+}
@@ -0,0 +1,99 @@
+package omit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/blink-io/opt"
+	"github.com/blink-io/opt/internal/globaldata"
+)
+
+var (
+	typesByName sync.Map // map[string]reflect.Type
+	typeNames   sync.Map // map[reflect.Type]string
+)
+
+// typedJSON is the envelope written by MarshalJSONTyped.
+type typedJSON struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// RegisterType registers name for the concrete type C so that MarshalJSONTyped
+// and UnmarshalJSONTyped can record and restore it, typically for each
+// implementation of an interface held in a Val. Registering C or name again
+// replaces the earlier registration.
+func RegisterType[C any](name string) {
+	typ := reflect.TypeFor[C]()
+	typesByName.Store(name, typ)
+	typeNames.Store(typ, name)
+}
+
+// MarshalJSONTyped marshals the value inside an envelope that records the
+// registered name of its concrete type, as in
+// {"type":"circle","value":{"radius":2}}, so that a Val of an interface type
+// can be decoded again with UnmarshalJSONTyped. An unset value marshals as
+// null. It is an error if the concrete type was not registered with
+// RegisterType.
+func (v Val[T]) MarshalJSONTyped() ([]byte, error) {
+	if v.state != StateSet {
+		return globaldata.JSONNull, nil
+	}
+
+	typ := reflect.TypeOf(v.value)
+	if typ == nil {
+		return nil, errors.New("cannot marshal nil interface value with its type")
+	}
+	name, ok := typeNames.Load(typ)
+	if !ok {
+		return nil, fmt.Errorf("no type registered for %s", typ)
+	}
+
+	payload, err := opt.JSONMarshal(v.value)
+	if err != nil {
+		return nil, err
+	}
+	return opt.JSONMarshal(typedJSON{Type: name.(string), Value: payload})
+}
+
+// UnmarshalJSONTyped is the inverse of MarshalJSONTyped. The payload is
+// decoded into a new value of the type registered under the envelope's name,
+// which must be assignable to T. Empty data makes the value unset and, as with
+// UnmarshalJSON, a null is an error.
+func (v *Val[T]) UnmarshalJSONTyped(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case len(data) == 0:
+		v.Unset()
+		return nil
+	case bytes.Equal(data, globaldata.JSONNull):
+		return errors.New("cannot unmarshal 'null' value into omit value")
+	}
+
+	var env typedJSON
+	if err := opt.JSONUnmarshal(data, &env); err != nil {
+		return err
+	}
+	typ, ok := typesByName.Load(env.Type)
+	if !ok {
+		return fmt.Errorf("no type registered with name %q", env.Type)
+	}
+
+	ptr := reflect.New(typ.(reflect.Type))
+	if err := opt.JSONUnmarshal(env.Value, ptr.Interface()); err != nil {
+		return err
+	}
+	val, ok := ptr.Elem().Interface().(T)
+	if !ok {
+		return fmt.Errorf("type %s registered as %q cannot be stored in %s", ptr.Elem().Type(), env.Type, reflect.TypeFor[T]())
+	}
+
+	v.value = val
+	v.state = StateSet
+	return nil
+}
@@ -0,0 +1,82 @@
+package omit
+
+import (
+	"math"
+	"testing"
+)
+
+type shape interface {
+	Area() float64
+}
+
+type circle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c circle) Area() float64 { return math.Pi * c.Radius * c.Radius }
+
+type rect struct {
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+func (r *rect) Area() float64 { return r.W * r.H }
+
+func init() {
+	RegisterType[circle]("circle")
+	RegisterType[*rect]("rect")
+}
+
+func TestJSONTyped(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		val  Val[shape]
+		json string
+	}{
+		{val: From[shape](circle{Radius: 2}), json: `{"type":"circle","value":{"radius":2}}`},
+		{val: From[shape](&rect{W: 2, H: 3}), json: `{"type":"rect","value":{"w":2,"h":3}}`},
+	}
+
+	for _, test := range tests {
+		b, err := test.val.MarshalJSONTyped()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.json {
+			t.Error("wrong json:", string(b))
+		}
+
+		var got Val[shape]
+		if err := got.UnmarshalJSONTyped(b); err != nil {
+			t.Fatal(err)
+		}
+		checkState(t, got, StateSet)
+		if got.MustGet().Area() != test.val.MustGet().Area() {
+			t.Errorf("wrong value: %#v", got.MustGet())
+		}
+	}
+
+	if b, err := (Val[shape]{}).MarshalJSONTyped(); err != nil || string(b) != "null" {
+		t.Error("unset should marshal as null:", string(b), err)
+	}
+
+	var got Val[shape]
+	if err := got.UnmarshalJSONTyped([]byte(`{"type":"triangle","value":{}}`)); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+	if err := got.UnmarshalJSONTyped([]byte(`null`)); err == nil {
+		t.Error("expected an error for null")
+	}
+	checkState(t, got, StateUnset)
+
+	var num Val[int]
+	if err := num.UnmarshalJSONTyped([]byte(`{"type":"circle","value":{"radius":1}}`)); err == nil {
+		t.Error("expected an error for a type that is not assignable")
+	}
+
+	type square struct{ Side float64 }
+	if _, err := From[any](square{}).MarshalJSONTyped(); err == nil {
+		t.Error("expected an error for an unregistered type")
+	}
+}
@@ -1,13 +1,30 @@
 // Package omitnull exposes a Val(ue) type that wraps a regular value with the
 // ability to be 'omitted/unset' or 'null'.
+//
+// Values convert to and from the two state types of the omit and null
+// packages as laid out below. The conversions into this package are lossless.
+// Those out of it either report the state that has no equivalent (GetOmit,
+// GetNull and their Must variants) or collapse it into the other kind of
+// absence (ToOmit and ToNull). They all live in this package because omit
+// and null cannot import it back.
+//
+//	from        to          unset     null      set
+//	omit        omitnull    unset     -         set      FromOmit
+//	null        omitnull    -         null      set      FromNull
+//	omitnull    omit        unset     unset     set      ToOmit
+//	omitnull    null        null      null      set      ToNull
 package omitnull
 
 import (
 	"bytes"
 	"database/sql/driver"
 	"encoding"
+	"encoding/gob"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/blink-io/opt"
 	"github.com/blink-io/opt/internal/globaldata"
@@ -65,6 +82,17 @@ func FromPtr[T any](val *T) Val[T] {
 	}
 }
 
+// Null returns a value which is considered 'null'.
+func Null[T any]() Val[T] {
+	return Val[T]{state: StateNull}
+}
+
+// Unset returns a value which is considered 'unset', the same as the zero
+// value.
+func Unset[T any]() Val[T] {
+	return Val[T]{}
+}
+
 // FromNull constructs a value from a nullable value. This is a lossless
 // conversion and cannot fail.
 func FromNull[T any](val null.Val[T]) Val[T] {
@@ -110,6 +138,28 @@ func (v Val[T]) GetOrZero() T {
 	return v.value
 }
 
+// GetOrErr returns the value, or the zero value and err if it is null or unset. It
+// is for code where a missing value is an error:
+//
+//	id, err := req.ID.GetOrErr(errMissingID)
+func (v Val[T]) GetOrErr(err error) (T, error) {
+	if v.state != StateSet {
+		var t T
+		return t, err
+	}
+	return v.value, nil
+}
+
+// GetOrErrf is GetOrErr with an error made by fmt.Errorf, which is only
+// called if the value is null or unset.
+func (v Val[T]) GetOrErrf(format string, args ...any) (T, error) {
+	if v.state != StateSet {
+		var t T
+		return t, fmt.Errorf(format, args...)
+	}
+	return v.value, nil
+}
+
 // GetNull retrieves the value as a nullable value.
 func (v Val[T]) GetNull() (null.Val[T], bool) {
 	switch v.state {
@@ -134,6 +184,24 @@ func (v Val[T]) GetOmit() (omit.Val[T], bool) {
 	}
 }
 
+// ToOmit converts the value to an omittable value, a null value becomes unset.
+// Use GetOmit to tell null apart instead.
+func (v Val[T]) ToOmit() omit.Val[T] {
+	if v.state == StateSet {
+		return omit.From(v.value)
+	}
+	return omit.Val[T]{}
+}
+
+// ToNull converts the value to a nullable value, an unset value becomes null.
+// Use GetNull to tell unset apart instead.
+func (v Val[T]) ToNull() null.Val[T] {
+	if v.state == StateSet {
+		return null.From(v.value)
+	}
+	return null.Val[T]{}
+}
+
 // MustGet retrieves the value or panics if it's null or omitted
 func (v Val[T]) MustGet() T {
 	val, ok := v.Get()
@@ -191,6 +259,29 @@ func (v Val[T]) Or(other Val[T]) Val[T] {
 	}
 }
 
+// OrElse is a lazy Or, fn is only called to build the fallback when v is not
+// set.
+func (v Val[T]) OrElse(fn func() Val[T]) Val[T] {
+	if v.state == StateSet {
+		return v
+	}
+	return v.Or(fn())
+}
+
+// Coalesce returns the first set value in vals, like the SQL function of the
+// same name. If none are set it returns null if any of vals are null and an
+// unset value otherwise, following the same hierarchy as Or.
+func Coalesce[T any](vals ...Val[T]) Val[T] {
+	var result Val[T]
+	for _, v := range vals {
+		if v.state == StateSet {
+			return v
+		}
+		result = result.Or(v)
+	}
+	return result
+}
+
 // Map transforms the value inside if it is set, else it returns a value of the
 // same state.
 //
@@ -213,6 +304,17 @@ func Map[A any, B any](v Val[A], fn func(A) B) Val[B] {
 	return Val[B]{state: v.state}
 }
 
+// FlatMap is Map for a transform that itself returns an optional value, it
+// avoids ending up with a Val[Val[B]]. It short-circuits: fn is only called
+// when v is set, otherwise v's state (null or unset) is propagated without
+// calling fn. When fn is called its result is returned as-is.
+func FlatMap[A any, B any](v Val[A], fn func(A) Val[B]) Val[B] {
+	if v.state == StateSet {
+		return fn(v.value)
+	}
+	return Val[B]{state: v.state}
+}
+
 // Set the value (and the state to 'set')
 func (v *Val[T]) Set(val T) {
 	v.value = val
@@ -250,6 +352,11 @@ func (v Val[T]) IsValue() bool {
 	return v.state == StateSet
 }
 
+// IsSet is an alias of IsValue that reads better next to IsNull and IsUnset.
+func (v Val[T]) IsSet() bool {
+	return v.state == StateSet
+}
+
 // IsNull returns true if v contains a null value
 func (v Val[T]) IsNull() bool {
 	return v.state == StateNull
@@ -283,6 +390,16 @@ func (v Val[T]) State() state {
 	return v.state
 }
 
+// Ptr returns a pointer to a copy of the value, or nil if null or unset.
+// Changes made through the pointer do not affect v. Use MustPtr when unset
+// should not be conflated with null.
+func (v Val[T]) Ptr() *T {
+	if v.state == StateSet {
+		return &v.value
+	}
+	return nil
+}
+
 // MustPtr returns a pointer to the value, or nil if null, panics if it is not
 // one of (null, set).
 func (v Val[T]) MustPtr() *T {
@@ -319,7 +436,14 @@ func (v Val[T]) IfZero(then func()) {
 }
 
 // UnmarshalJSON implements json.Unmarshaler
+//
+// Empty (or all whitespace) data makes the value unset. The json package never
+// passes that, since it leaves values for missing keys alone, but it lets a
+// Val be reset by calling UnmarshalJSON(nil). Whitespace around a null is
+// ignored.
 func (v *Val[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
 	switch {
 	case len(data) == 0:
 		var zero T
@@ -332,7 +456,7 @@ func (v *Val[T]) UnmarshalJSON(data []byte) error {
 		v.state = StateNull
 		return nil
 	default:
-		err := opt.JSONUnmarshal(data, &v.value)
+		err := opt.UnmarshalValueJSON(data, &v.value)
 		if err != nil {
 			return err
 		}
@@ -355,12 +479,55 @@ func (v *Val[T]) UnmarshalJSON(data []byte) error {
 func (v Val[T]) MarshalJSON() ([]byte, error) {
 	switch v.state {
 	case StateSet:
-		return opt.JSONMarshal(v.value)
+		return opt.MarshalValueJSON(v.value)
 	default:
 		return globaldata.JSONNull, nil
 	}
 }
 
+// MarshalXML implements xml.Marshaler. An unset value emits no element at
+// all, a null value emits an empty element marked with xsi:nil="true" and a
+// set value is encoded as T would be.
+//
+// Parent elements from a path tag such as `xml:"a>b"` are written by the
+// encoder before MarshalXML is called, so they still appear when unset.
+func (v Val[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	switch v.state {
+	case StateUnset:
+		return nil
+	case StateNull:
+		start = globaldata.XMLNilElement(start)
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		return e.EncodeToken(start.End())
+	}
+	return e.EncodeElement(v.value, start)
+}
+
+// UnmarshalXML implements xml.Unmarshaler. An element marked with
+// xsi:nil="true" results in a null value and anything else is decoded as T,
+// a missing element leaves the value unset.
+func (v *Val[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if globaldata.IsXMLNil(start) {
+		if err := d.Skip(); err != nil {
+			return err
+		}
+		var zero T
+		v.value = zero
+		v.state = StateNull
+		return nil
+	}
+
+	var val T
+	if err := d.DecodeElement(&val, &start); err != nil {
+		return err
+	}
+	v.value = val
+	v.state = StateSet
+	return nil
+}
+
 // MarshalText implements encoding.TextMarshaler. If the value
 // is omitted it will return nil (empty string), if the value
 // is null it will return '0' as a representation, and if the
@@ -369,6 +536,9 @@ func (v Val[T]) MarshalJSON() ([]byte, error) {
 // That's also to say that there is no compatibility with the outside
 // world. A value that is Unmarshalled by this package must have been
 // produced by this package to encode the text properly.
+//
+// Set values are encoded by opt.MarshalValueText, which falls back to JSON
+// for structs, maps and slices.
 func (v Val[T]) MarshalText() ([]byte, error) {
 	switch v.state {
 	case StateUnset:
@@ -377,23 +547,11 @@ func (v Val[T]) MarshalText() ([]byte, error) {
 		return []byte{'0'}, nil
 	}
 
-	out := []byte{'1'}
-
-	refVal := reflect.ValueOf(v.value)
-	if refVal.Type().Implements(globaldata.EncodingTextMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextMarshaler)
-		b, err := valuer.MarshalText()
-		if err != nil {
-			return nil, err
-		}
-		return append(out, b...), nil
-	}
-
-	var text string
-	if err := opt.ConvertAssign(&text, v.value); err != nil {
+	b, err := opt.MarshalValueText(v.value)
+	if err != nil {
 		return nil, err
 	}
-	return append(out, text...), nil
+	return append([]byte{'1'}, b...), nil
 }
 
 // UnmarshalText implements encoding.TextUnmarshaler.
@@ -414,20 +572,13 @@ func (v *Val[T]) UnmarshalText(text []byte) error {
 
 	text = text[1:]
 
-	refVal := reflect.ValueOf(&v.value)
-	if refVal.Type().Implements(globaldata.EncodingTextUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextUnmarshaler)
-		if err := valuer.UnmarshalText(text); err != nil {
-			return err
-		}
-		v.state = StateSet
-		return nil
-	}
-
-	if err := opt.ConvertAssign(&v.value, string(text)); err != nil {
+	// Decode into a temporary so that v is untouched if decoding fails.
+	var val T
+	if err := opt.UnmarshalValueText(text, &val); err != nil {
 		return err
 	}
 
+	v.value = val
 	v.state = StateSet
 	return nil
 }
@@ -450,9 +601,9 @@ func (v Val[T]) MarshalBinary() ([]byte, error) {
 
 	out := []byte{1}
 
-	refVal := reflect.ValueOf(v.value)
-	if refVal.Type().Implements(globaldata.EncodingBinaryMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.BinaryMarshaler)
+	valType := reflect.TypeOf(v.value)
+	if globaldata.Implements(valType, globaldata.EncodingBinaryMarshalerIntf) {
+		valuer := any(v.value).(encoding.BinaryMarshaler)
 		b, err := valuer.MarshalBinary()
 		if err != nil {
 			return nil, err
@@ -460,8 +611,8 @@ func (v Val[T]) MarshalBinary() ([]byte, error) {
 		return append(out, b...), nil
 	}
 
-	if refVal.Type().Implements(globaldata.EncodingTextMarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextMarshaler)
+	if globaldata.Implements(valType, globaldata.EncodingTextMarshalerIntf) {
+		valuer := any(v.value).(encoding.TextMarshaler)
 		b, err := valuer.MarshalText()
 		if err != nil {
 			return nil, err
@@ -496,9 +647,9 @@ func (v *Val[T]) UnmarshalBinary(b []byte) error {
 
 	b = b[1:]
 
-	refVal := reflect.ValueOf(&v.value)
-	if refVal.Type().Implements(globaldata.EncodingBinaryUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.BinaryUnmarshaler)
+	ptrType := reflect.TypeFor[*T]()
+	if globaldata.Implements(ptrType, globaldata.EncodingBinaryUnmarshalerIntf) {
+		valuer := any(&v.value).(encoding.BinaryUnmarshaler)
 		if err := valuer.UnmarshalBinary(b); err != nil {
 			return err
 		}
@@ -506,8 +657,8 @@ func (v *Val[T]) UnmarshalBinary(b []byte) error {
 		return nil
 	}
 
-	if refVal.Type().Implements(globaldata.EncodingTextUnmarshalerIntf) {
-		valuer := refVal.Interface().(encoding.TextUnmarshaler)
+	if globaldata.Implements(ptrType, globaldata.EncodingTextUnmarshalerIntf) {
+		valuer := any(&v.value).(encoding.TextUnmarshaler)
 		if err := valuer.UnmarshalText(b); err != nil {
 			return err
 		}
@@ -523,17 +674,99 @@ func (v *Val[T]) UnmarshalBinary(b []byte) error {
 	return nil
 }
 
+// GobEncode implements gob.GobEncoder, without it gob would silently drop the
+// unexported fields of a Val. It uses the same state framing as MarshalBinary
+// (nothing for unset, 0 for null, 1 for set) followed, when set, by the gob
+// encoding of the value.
+func (v Val[T]) GobEncode() ([]byte, error) {
+	switch v.state {
+	case StateUnset:
+		return []byte{}, nil
+	case StateNull:
+		return []byte{0}, nil
+	}
+
+	buf := bytes.NewBuffer([]byte{1})
+	if err := gob.NewEncoder(buf).Encode(&v.value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder, reversing GobEncode. v is left
+// untouched when an error is returned.
+func (v *Val[T]) GobDecode(b []byte) error {
+	if len(b) == 0 {
+		var zero T
+		v.value = zero
+		v.state = StateUnset
+		return nil
+	}
+
+	switch b[0] {
+	case 0:
+		if len(b) != 1 {
+			return errors.New("invalid gob format for omitnull.Val: unexpected data after null state")
+		}
+		var zero T
+		v.value = zero
+		v.state = StateNull
+		return nil
+	case 1:
+	default:
+		return fmt.Errorf("invalid gob format for omitnull.Val: unknown state byte %d", b[0])
+	}
+
+	var val T
+	if err := gob.NewDecoder(bytes.NewReader(b[1:])).Decode(&val); err != nil {
+		return err
+	}
+	v.value = val
+	v.state = StateSet
+	return nil
+}
+
 // Scan implements the sql.Scanner interface. If the wrapped type implements
-// sql.Scanner then it will call that.
+// sql.Scanner then it will call that. Scanned time.Time values are converted
+// to opt.ScanLocation if it is set. A driver.Valuer source is first replaced
+// by its Value unless it is already a T, and sql.RawBytes is copied rather
+// than retained.
+//
+// Scanning NULL is handled differently by each of the optional packages,
+// pick the one that matches the column:
+//
+//	package     NULL         value
+//	omit        error        set
+//	null        null         set
+//	omitnull    null         set
+//
+// omitnull never scans as unset, as a column is always present in a row.
 func (v *Val[T]) Scan(value any) error {
+	value, err := globaldata.ScanSource[T](value)
+	if err != nil {
+		return err
+	}
+
 	if value == nil {
 		var zero T
 		v.value = zero
 		v.state = StateNull
 		return nil
 	}
+
+	// Scan into a temporary so that v is untouched if the conversion fails.
+	var val T
+	if err := opt.ConvertAssign(&val, value); err != nil {
+		return err
+	}
+
+	if t, ok := any(&val).(*time.Time); ok && opt.ScanLocation != nil {
+		*t = t.In(opt.ScanLocation)
+	}
+
+	v.value = val
 	v.state = StateSet
-	return opt.ConvertAssign(&v.value, value)
+	return nil
 }
 
 // Value implements the driver.Valuer interface. If the underlying type
@@ -551,6 +784,13 @@ func (v Val[T]) Value() (driver.Value, error) {
 		return nil, nil
 	}
 
+	// Payloads that are already driver values need no conversion, skip the
+	// interface checks and reflection of ToDriverValue for them.
+	switch val := any(v.value).(type) {
+	case int64, float64, bool, string, []byte, time.Time:
+		return val, nil
+	}
+
 	return opt.ToDriverValue(v.value)
 }
 
@@ -567,3 +807,17 @@ func Equal[T comparable](a, b Val[T]) bool {
 
 	return a.value == b.value
 }
+
+// EqualFunc is Equal for any T, using eq to compare set values. It is useful
+// for values that are not comparable such as slices.
+func EqualFunc[T any](a, b Val[T], eq func(T, T) bool) bool {
+	if a.state != b.state {
+		return false
+	}
+
+	if a.state != StateSet {
+		return true
+	}
+
+	return eq(a.value, b.value)
+}
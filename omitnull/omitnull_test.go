@@ -3,7 +3,12 @@ package omitnull
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/gob"
+	"encoding/xml"
+	"errors"
 	"net"
+	"reflect"
+	"slices"
 	"testing"
 	"time"
 
@@ -37,6 +42,20 @@ func TestConstruction(t *testing.T) {
 	if !val.IsUnset() {
 		t.Error("should be unset")
 	}
+
+	val = Null[string]()
+	checkState(t, val, StateNull)
+	if _, ok := val.Get(); ok {
+		t.Error("null should have no value")
+	}
+	val = Unset[string]()
+	checkState(t, val, StateUnset)
+	if _, ok := val.Get(); ok {
+		t.Error("unset should have no value")
+	}
+	if val = From("hello"); !val.IsSet() || val.IsNull() || val.IsUnset() {
+		t.Error("should only be set")
+	}
 }
 
 func TestConversions(t *testing.T) {
@@ -115,6 +134,30 @@ func TestConversions(t *testing.T) {
 	}
 }
 
+func TestLossyConversions(t *testing.T) {
+	t.Parallel()
+
+	if o := From(5).ToOmit(); o.GetOr(0) != 5 {
+		t.Error("wrong value:", o)
+	}
+	if o := Null[int]().ToOmit(); !o.IsUnset() {
+		t.Error("null should become unset")
+	}
+	if o := Unset[int]().ToOmit(); !o.IsUnset() {
+		t.Error("should stay unset")
+	}
+
+	if n := From(5).ToNull(); n.GetOr(0) != 5 {
+		t.Error("wrong value:", n)
+	}
+	if n := Unset[int]().ToNull(); !n.IsNull() {
+		t.Error("unset should become null")
+	}
+	if n := Null[int]().ToNull(); !n.IsNull() {
+		t.Error("should stay null")
+	}
+}
+
 func TestConversionOmitFail(t *testing.T) {
 	t.Parallel()
 
@@ -242,6 +285,72 @@ func TestOr(t *testing.T) {
 	}
 }
 
+func TestGetOrErr(t *testing.T) {
+	t.Parallel()
+
+	errMissing := errors.New("missing")
+	if v, err := From(5).GetOrErr(errMissing); err != nil || v != 5 {
+		t.Error("wrong result:", v, err)
+	}
+	if v, err := Null[int]().GetOrErr(errMissing); !errors.Is(err, errMissing) || v != 0 {
+		t.Error("expected the error, got:", v, err)
+	}
+	if _, err := (Val[int]{}).GetOrErr(errMissing); !errors.Is(err, errMissing) {
+		t.Error("unset should return the error, got:", err)
+	}
+
+	if v, err := From(5).GetOrErrf("missing %s", "id"); err != nil || v != 5 {
+		t.Error("wrong result:", v, err)
+	}
+	if _, err := Null[int]().GetOrErrf("missing %s: %w", "id", errMissing); err == nil ||
+		err.Error() != "missing id: missing" || !errors.Is(err, errMissing) {
+		t.Error("wrong error:", err)
+	}
+}
+
+func TestOrElse(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	fallback := func() Val[int] {
+		called = true
+		return From(6)
+	}
+
+	if From(5).OrElse(fallback).MustGet() != 5 {
+		t.Error("it should have returned 5")
+	}
+	if called {
+		t.Error("fallback should not be called when set")
+	}
+	if (Val[int]{}).OrElse(fallback).MustGet() != 6 {
+		t.Error("it should have returned 6")
+	}
+	if !called {
+		t.Error("fallback should be called when unset")
+	}
+
+	var null Val[int]
+	null.Null()
+	if !null.OrElse(func() Val[int] { return Val[int]{} }).IsNull() {
+		t.Error("null should win over unset")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	t.Parallel()
+
+	var null, unset Val[int]
+	null.Null()
+
+	if val := Coalesce(unset, null, From(5), From(6)); val.MustGet() != 5 {
+		t.Error("it should have returned 5")
+	}
+	checkState(t, Coalesce(unset, null, unset), StateNull)
+	checkState(t, Coalesce(unset, unset), StateUnset)
+	checkState(t, Coalesce[int](), StateUnset)
+}
+
 func TestMap(t *testing.T) {
 	t.Parallel()
 
@@ -261,6 +370,59 @@ func TestMap(t *testing.T) {
 	}
 }
 
+func TestFlatMap(t *testing.T) {
+	t.Parallel()
+
+	names := map[int]string{1: "one"}
+	lookup := func(i int) Val[string] {
+		name, ok := names[i]
+		if !ok {
+			return Val[string]{}
+		}
+		return From(name)
+	}
+
+	if FlatMap(From(1), lookup).MustGet() != "one" {
+		t.Error("wrong value")
+	}
+	checkState(t, FlatMap(From(2), lookup), StateUnset)
+
+	var null Val[int]
+	null.Null()
+	checkState(t, FlatMap(null, lookup), StateNull)
+	called := false
+	checkState(t, FlatMap(Val[int]{}, func(int) Val[string] {
+		called = true
+		return From("")
+	}), StateUnset)
+	if called {
+		t.Error("fn should not be called when v is not set")
+	}
+}
+
+func TestPtr(t *testing.T) {
+	t.Parallel()
+
+	val := From(5)
+	ptr := val.Ptr()
+	if ptr == nil || *ptr != 5 {
+		t.Fatal("wrong pointer")
+	}
+	*ptr = 6
+	if val.MustGet() != 5 {
+		t.Error("mutating through the pointer should not change the value")
+	}
+
+	val.Null()
+	if val.Ptr() != nil {
+		t.Error("null should be nil")
+	}
+
+	if (Val[int]{}).Ptr() != nil {
+		t.Error("unset should be nil")
+	}
+}
+
 func TestChanges(t *testing.T) {
 	t.Parallel()
 
@@ -324,6 +486,31 @@ func TestMarshalJSONIsZero(t *testing.T) {
 	}
 }
 
+func TestUnmarshalJSONPadding(t *testing.T) {
+	t.Parallel()
+
+	val := From("hello")
+	if err := val.UnmarshalJSON([]byte{}); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateUnset)
+	val = From("hello")
+	if err := val.UnmarshalJSON([]byte(" \n\t")); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateUnset)
+
+	if err := val.UnmarshalJSON([]byte(" null\n")); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateNull)
+
+	if err := val.UnmarshalJSON([]byte(` "hello" `)); err != nil {
+		t.Error(err)
+	}
+	checkState(t, val, StateSet)
+}
+
 func TestUnmarshalJSON(t *testing.T) {
 	t.Parallel()
 
@@ -348,6 +535,112 @@ func TestUnmarshalJSON(t *testing.T) {
 	checkState(t, hello, StateUnset)
 }
 
+func TestUnmarshalJSONStates(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name Val[string] `json:"name"`
+	}
+
+	tests := []struct {
+		json  string
+		state state
+	}{
+		{json: `{}`, state: StateUnset},
+		{json: `{"name":null}`, state: StateNull},
+		{json: `{"name":"hello"}`, state: StateSet},
+	}
+
+	for _, test := range tests {
+		var req request
+		if err := opt.JSONUnmarshal([]byte(test.json), &req); err != nil {
+			t.Fatal(test.json, err)
+		}
+		checkState(t, req.Name, test.state)
+
+		b, err := opt.JSONMarshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var again request
+		if err := opt.JSONUnmarshal(b, &again); err != nil {
+			t.Fatal(err)
+		}
+		if test.state != StateUnset {
+			// Without an omit-aware encoder unset is written as null, so only
+			// null and set survive a round trip.
+			checkState(t, again.Name, test.state)
+		}
+	}
+
+	var req request
+	if err := opt.JSONUnmarshal([]byte(`{"name":"hello"}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Name.MustGet() != "hello" {
+		t.Error("wrong value:", req.Name)
+	}
+	if err := opt.JSONUnmarshal([]byte(`{"name":null}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, req.Name, StateNull)
+	if err := opt.JSONUnmarshal([]byte(`{}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	// A missing key does not touch a field that was already decoded.
+	checkState(t, req.Name, StateNull)
+}
+
+type xmlAddress struct {
+	City Val[string] `xml:"city"`
+	Zip  Val[int]    `xml:"zip"`
+}
+
+type xmlPerson struct {
+	XMLName xml.Name        `xml:"person"`
+	Name    Val[string]     `xml:"name"`
+	Age     Val[int]        `xml:"age"`
+	Email   Val[string]     `xml:"email"`
+	Address Val[xmlAddress] `xml:"address"`
+}
+
+func TestXML(t *testing.T) {
+	t.Parallel()
+
+	var age Val[int]
+	age.Null()
+	in := xmlPerson{
+		Name:    From("hello"),
+		Age:     age,
+		Address: From(xmlAddress{City: From("Paris")}),
+	}
+	b, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<person><name>hello</name>` +
+		`<age xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:nil="true"></age>` +
+		`<address><city>Paris</city></address></person>`
+	if string(b) != want {
+		t.Errorf("wrong xml:\nwant: %s\ngot:  %s", want, b)
+	}
+
+	var out xmlPerson
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name.MustGet() != "hello" {
+		t.Error("wrong name:", out.Name)
+	}
+	checkState(t, out.Age, StateNull)
+	checkState(t, out.Email, StateUnset)
+	address := out.Address.MustGet()
+	if address.City.MustGet() != "Paris" {
+		t.Error("wrong city:", address.City)
+	}
+	checkState(t, address.Zip, StateUnset)
+}
+
 func TestMarshalText(t *testing.T) {
 	t.Parallel()
 
@@ -377,6 +670,38 @@ func TestMarshalText(t *testing.T) {
 	}
 }
 
+func TestTextJSONFallback(t *testing.T) {
+	t.Parallel()
+
+	tags := From([]string{"a", "b"})
+	b, err := tags.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `1["a","b"]` {
+		t.Error("wrong text:", string(b))
+	}
+
+	var got Val[[]string]
+	if err := got.UnmarshalText(b); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.MustGet(), []string{"a", "b"}) {
+		t.Error("wrong value:", got.MustGet())
+	}
+
+	type point struct {
+		X, Y int
+	}
+	var p Val[point]
+	if err := p.UnmarshalText([]byte(`1{"X":1,"Y":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if p.MustGet() != (point{X: 1, Y: 2}) {
+		t.Error("wrong point:", p.MustGet())
+	}
+}
+
 func TestUnmarshalText(t *testing.T) {
 	t.Parallel()
 
@@ -489,6 +814,50 @@ func TestUnmarshalBinary(t *testing.T) {
 	}
 }
 
+type gobRecord struct {
+	Name    Val[string]
+	Unset   Val[string]
+	Count   Val[int]
+	Zero    Val[int]
+	Tags    Val[[]string]
+	Created Val[time.Time]
+	Missing Val[float64]
+}
+
+func TestGob(t *testing.T) {
+	t.Parallel()
+
+	var null Val[float64]
+	null.Null()
+	in := gobRecord{
+		Name:    From("hello"),
+		Count:   From(5),
+		Zero:    From(0),
+		Tags:    From([]string{"a", "b"}),
+		Created: From(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Missing: null,
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out gobRecord
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip mismatch:\nwant: %#v\ngot:  %#v", in, out)
+	}
+
+	checkState(t, out.Missing, StateNull)
+	checkState(t, out.Unset, StateUnset)
+	var val Val[int]
+	if err := val.GobDecode([]byte{2}); err == nil {
+		t.Error("expected an error for an unknown state byte")
+	}
+}
+
 func TestScan(t *testing.T) {
 	t.Parallel()
 
@@ -507,6 +876,24 @@ func TestScan(t *testing.T) {
 	}
 }
 
+func TestScanNull(t *testing.T) {
+	t.Parallel()
+
+	for _, val := range []Val[int]{From(5), Null[int](), Unset[int]()} {
+		if err := val.Scan(nil); err != nil {
+			t.Fatal(err)
+		}
+		checkState(t, val, StateNull)
+	}
+
+	// A nil Valuer is resolved to NULL before the state is decided.
+	val := Unset[int]()
+	if err := val.Scan((*valuerImplementation)(nil)); err != nil {
+		t.Fatal(err)
+	}
+	checkState(t, val, StateNull)
+}
+
 type valuerImplementation struct{}
 
 func (valuerImplementation) Value() (driver.Value, error) {
@@ -608,6 +995,37 @@ func TestEqual(t *testing.T) {
 	}
 }
 
+func TestEqualFunc(t *testing.T) {
+	t.Parallel()
+
+	a := Val[[]int]{}
+	b := Val[[]int]{}
+	if !EqualFunc(a, b, slices.Equal) {
+		t.Error("should be equal")
+	}
+
+	b.Null()
+	if EqualFunc(a, b, slices.Equal) {
+		t.Error("unset should not equal null")
+	}
+
+	a.Null()
+	if !EqualFunc(a, b, slices.Equal) {
+		t.Error("should be equal")
+	}
+
+	a.Set([]int{1, 2})
+	b.Set([]int{1, 2})
+	if !EqualFunc(a, b, slices.Equal) {
+		t.Error("should be equal")
+	}
+
+	b.Set([]int{2, 1})
+	if EqualFunc(a, b, slices.Equal) {
+		t.Error("should not be equal")
+	}
+}
+
 func checkState[T any](t *testing.T, val Val[T], want state) {
 	t.Helper()
 
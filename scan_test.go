@@ -0,0 +1,88 @@
+package opt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blink-io/opt"
+	"github.com/blink-io/opt/null"
+	"github.com/blink-io/opt/omit"
+	"github.com/blink-io/opt/omitnull"
+)
+
+// The Scan methods of the three packages each apply ScanLocation themselves,
+// so they are checked side by side here.
+func TestScanLocation(t *testing.T) {
+	zone := time.FixedZone("here", -3600*8)
+	scanned := time.Date(2000, 1, 1, 2, 30, 0, 0, zone)
+
+	type timeScanner interface {
+		Scan(value any) error
+		MustGet() time.Time
+	}
+	vals := []struct {
+		name string
+		val  timeScanner
+	}{
+		{"omit", new(omit.Val[time.Time])},
+		{"null", new(null.Val[time.Time])},
+		{"omitnull", new(omitnull.Val[time.Time])},
+	}
+
+	for _, tc := range vals {
+		if err := tc.val.Scan(scanned); err != nil {
+			t.Fatal(tc.name, err)
+		}
+		if tc.val.MustGet().Location() != zone {
+			t.Error(tc.name, "location should be preserved by default")
+		}
+	}
+
+	opt.ScanLocation = time.UTC
+	defer func() { opt.ScanLocation = nil }()
+
+	for _, tc := range vals {
+		if err := tc.val.Scan(scanned); err != nil {
+			t.Fatal(tc.name, err)
+		}
+		if got := tc.val.MustGet(); got.Location() != time.UTC || !got.Equal(scanned) {
+			t.Error(tc.name, "expected the same instant in UTC, got:", got)
+		}
+	}
+}
+
+// checkFailureUnchanged makes a failing Scan and UnmarshalText on a copy of
+// val and checks that both leave it as it was.
+func checkFailureUnchanged[V comparable, P interface {
+	*V
+	Scan(value any) error
+	UnmarshalText(text []byte) error
+}](t *testing.T, name string, val V, badText string) {
+	t.Helper()
+
+	got := val
+	if err := P(&got).Scan("foo"); err == nil {
+		t.Error(name, "expected a scan error")
+	}
+	if got != val {
+		t.Error(name, "a failed scan should leave the value unchanged, got:", got)
+	}
+	if err := P(&got).UnmarshalText([]byte(badText)); err == nil {
+		t.Error(name, "expected an unmarshal error")
+	}
+	if got != val {
+		t.Error(name, "a failed unmarshal should leave the value unchanged, got:", got)
+	}
+}
+
+func TestScanFailureUnchanged(t *testing.T) {
+	t.Parallel()
+
+	checkFailureUnchanged(t, "omit set", omit.From(5), "foo")
+	checkFailureUnchanged(t, "omit unset", omit.Val[int]{}, "foo")
+	checkFailureUnchanged(t, "null set", null.From(5), "foo")
+	checkFailureUnchanged(t, "null null", null.Val[int]{}, "foo")
+	checkFailureUnchanged(t, "omitnull set", omitnull.From(5), "1foo")
+	checkFailureUnchanged(t, "omitnull null", omitnull.Null[int](), "1foo")
+	checkFailureUnchanged(t, "omitnull unset", omitnull.Val[int]{}, "1foo")
+}
@@ -0,0 +1,53 @@
+package opt
+
+import (
+	"encoding"
+	"reflect"
+
+	"github.com/blink-io/opt/internal/globaldata"
+)
+
+// MarshalValueText marshals the payload of an optional value to text. It
+// uses encoding.TextMarshaler if T implements it. Otherwise structs, maps,
+// arrays and slices other than []byte, which have no text form of their own,
+// are encoded as JSON with JSONMarshal, and anything else is converted to a
+// string with ConvertAssign.
+func MarshalValueText[T any](val T) ([]byte, error) {
+	if globaldata.Implements(reflect.TypeOf(val), globaldata.EncodingTextMarshalerIntf) {
+		return any(val).(encoding.TextMarshaler).MarshalText()
+	}
+
+	if textAsJSON(reflect.TypeFor[T]()) {
+		return JSONMarshal(&val)
+	}
+
+	var text string
+	if err := ConvertAssign(&text, val); err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+// UnmarshalValueText is the inverse of MarshalValueText.
+func UnmarshalValueText[T any](text []byte, val *T) error {
+	if globaldata.Implements(reflect.TypeFor[*T](), globaldata.EncodingTextUnmarshalerIntf) {
+		return any(val).(encoding.TextUnmarshaler).UnmarshalText(text)
+	}
+
+	if textAsJSON(reflect.TypeFor[T]()) {
+		return JSONUnmarshal(text, val)
+	}
+
+	return ConvertAssign(val, string(text))
+}
+
+// textAsJSON reports whether values of t are encoded as JSON in text.
+func textAsJSON(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package opt
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func checkValueText[T any](t *testing.T, val T, text string) {
+	t.Helper()
+
+	b, err := MarshalValueText(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != text {
+		t.Errorf("%T: want %s, got %s", val, text, b)
+	}
+
+	var got T
+	if err := UnmarshalValueText(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, val) {
+		t.Errorf("%T: round trip gave %v", val, got)
+	}
+}
+
+func TestValueText(t *testing.T) {
+	t.Parallel()
+
+	type point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+
+	checkValueText(t, 5, "5")
+	checkValueText(t, "hello", "hello")
+	checkValueText(t, []byte("raw"), "raw")
+	checkValueText(t, net.IPv4(127, 0, 0, 1), "127.0.0.1")
+	checkValueText(t, []string{"a", "b"}, `["a","b"]`)
+	checkValueText(t, map[string]int{"a": 1}, `{"a":1}`)
+	checkValueText(t, point{X: 1, Y: 2}, `{"x":1,"y":2}`)
+	checkValueText(t, [2]int{1, 2}, `[1,2]`)
+
+	var p point
+	if err := UnmarshalValueText([]byte(`{"x":`), &p); err == nil {
+		t.Error("expected an error for bad json")
+	}
+}
@@ -9,32 +9,12 @@ import (
 	"github.com/blink-io/opt/internal/globaldata"
 )
 
-// callValuerValue returns vr.Value(), with one exception:
-// If vr.Value is an auto-generated method on a pointer type and the
-// pointer is nil, it would panic at runtime in the panicwrap
-// method. Treat it like nil instead.
-// Issue 8415.
-//
-// This is so people can implement driver.Value on value types and
-// still use nil pointers to those types to mean nil/NULL, just like
-// string/*string.
-//
-// This function is mirrored in the database/sql package.
-func callValuerValue(vr driver.Valuer) (v driver.Value, err error) {
-	if rv := reflect.ValueOf(vr); rv.Kind() == reflect.Pointer &&
-		rv.IsNil() &&
-		rv.Type().Elem().Implements(globaldata.DriverValuerIntf) {
-		return nil, nil
-	}
-	return vr.Value()
-}
-
 // ToDriverValue generates the appropriate driver.Value
 // from a given value
 func ToDriverValue(val any) (driver.Value, error) {
 	switch vr := val.(type) {
 	case driver.Valuer:
-		sv, err := callValuerValue(vr)
+		sv, err := globaldata.CallValuerValue(vr)
 		if err != nil {
 			return nil, err
 		}
@@ -53,20 +33,21 @@ func ToDriverValue(val any) (driver.Value, error) {
 		return val, nil
 	}
 
-	refVal := reflect.ValueOf(val)
+	refType := reflect.TypeOf(val)
 
 	// If it implements encoding.TextMarshaler, use that.
-	if refVal.Type().Implements(globaldata.EncodingTextMarshalerIntf) {
-		marshaler := refVal.Interface().(encoding.TextMarshaler)
+	if globaldata.Implements(refType, globaldata.EncodingTextMarshalerIntf) {
+		marshaler := val.(encoding.TextMarshaler)
 		return marshaler.MarshalText()
 	}
 
 	// If it implements encoding.BinaryMarshaler, use that.
-	if refVal.Type().Implements(globaldata.EncodingBinaryMarshalerIntf) {
-		marshaler := refVal.Interface().(encoding.BinaryMarshaler)
+	if globaldata.Implements(refType, globaldata.EncodingBinaryMarshalerIntf) {
+		marshaler := val.(encoding.BinaryMarshaler)
 		return marshaler.MarshalBinary()
 	}
 
+	refVal := reflect.ValueOf(val)
 	switch refVal.Kind() {
 	case reflect.Pointer:
 		// indirect pointers
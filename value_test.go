@@ -59,3 +59,55 @@ func doTest[E any, T any](t *testing.T, v T) {
 		}
 	})
 }
+
+type textValue struct{}
+
+func (textValue) MarshalText() ([]byte, error) { return []byte("text"), nil }
+
+type binaryValue struct{}
+
+func (binaryValue) MarshalBinary() ([]byte, error) { return []byte("binary"), nil }
+
+func TestToDriverValueMarshalers(t *testing.T) {
+	// Run twice so the second pass is answered from the interface cache.
+	for i := 0; i < 2; i++ {
+		for _, tc := range []struct {
+			val  any
+			want string
+		}{
+			{textValue{}, "text"},
+			{binaryValue{}, "binary"},
+			{customString("plain"), "plain"},
+		} {
+			drVal, err := ToDriverValue(tc.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			switch d := drVal.(type) {
+			case []byte:
+				got = string(d)
+			case string:
+				got = d
+			}
+			if got != tc.want {
+				t.Errorf("%T: want %q, got %#v", tc.val, tc.want, drVal)
+			}
+		}
+	}
+}
+
+func BenchmarkToDriverValue(b *testing.B) {
+	b.Run("custom", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_, _ = ToDriverValue(customInt(5))
+		}
+	})
+	b.Run("marshaler", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_, _ = ToDriverValue(textValue{})
+		}
+	})
+}